@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"my-chi-app/internal/config"
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/ratelimit"
+	"my-chi-app/internal/scheduler"
+	"my-chi-app/internal/storage"
+)
+
+// newScheduler registers the maintenance jobs with their configured timing, an interval of 0 disables a job
+// rateLimitStore is nil when rate limits are kept in memory, they need no cleanup then
+func newScheduler(cfg config.SchedulerConfig, db *sql.DB, s3Client *storage.S3Client, rateLimitStore *ratelimit.PostgresStore) *scheduler.Scheduler {
+	s := scheduler.New(db, slog.Default())
+	s.Add(scheduler.PurgeTokensJob(
+		cfg.PurgeTokensInterval,
+		cfg.PurgeTokensJitter,
+		cfg.TokenPurgeGrace,
+		repository.NewTokenRepository(db),
+		repository.NewAccountTokenRepository(db),
+	))
+	s.Add(scheduler.NotificationCleanupJob(
+		cfg.NotificationCleanupInterval,
+		cfg.NotificationCleanupJitter,
+		cfg.NotificationRetention,
+		repository.NewNotificationRepository(db),
+	))
+	s.Add(scheduler.RankingRefreshJob(
+		cfg.RankingRefreshInterval,
+		cfg.RankingRefreshJitter,
+		repository.NewPostRepository(db),
+	))
+	s.Add(scheduler.UploadCleanupJob(
+		cfg.UploadCleanupInterval,
+		cfg.UploadCleanupJitter,
+		cfg.UploadOrphanAge,
+		repository.NewImageRepository(db),
+		s3Client,
+	))
+	if rateLimitStore != nil {
+		s.Add(scheduler.RateLimitCleanupJob(
+			cfg.RateLimitCleanupInterval,
+			cfg.RateLimitCleanupJitter,
+			rateLimitStore,
+		))
+	}
+	return s
+}
+
+// startScheduler starts the maintenance jobs unless the scheduler is disabled
+// The returned stop function waits for running jobs to return
+func startScheduler(ctx context.Context, cfg config.SchedulerConfig, db *sql.DB, s3Client *storage.S3Client, rateLimitStore *ratelimit.PostgresStore) func() {
+	if !cfg.Enabled {
+		return func() {}
+	}
+	s := newScheduler(cfg, db, s3Client, rateLimitStore)
+	s.Start(ctx)
+	return s.Stop
+}
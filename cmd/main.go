@@ -2,21 +2,58 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
-	"net/http"
+	"log/slog"
 	"os"
+	"strings"
 
+	"my-chi-app/internal/config"
 	"my-chi-app/internal/database"
-	"my-chi-app/internal/database/repository"
-	httpdelivery "my-chi-app/internal/delivery/http"
-	"my-chi-app/internal/storage"
 
 	_ "my-chi-app/docs"
 
 	"github.com/joho/godotenv"
-	httpSwagger "github.com/swaggo/http-swagger"
+	"gopkg.in/yaml.v2"
 )
 
+// Exit codes of the CLI, usage errors follow the flag package convention
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// command is a subcommand of the app binary
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, cfg *config.Config, args []string) error
+}
+
+// commands lists the subcommands in the order they are shown in the usage
+var commands = []command{
+	{name: "serve", summary: "start the HTTP server (default)", run: runServe},
+	{name: "migrate", summary: "apply, revert or list schema migrations", run: runMigrate},
+	{name: "create-admin", summary: "create an admin account or promote an existing user", run: runCreateAdmin},
+	{name: "purge-tokens", summary: "delete expired sessions and account tokens", run: runPurgeTokens},
+	{name: "seed", summary: "fill the database with demo data", run: runSeed},
+	{name: "reindex-search", summary: "rebuild the full-text search indexes", run: runReindexSearch},
+	{name: "config", summary: "print the effective configuration with secrets redacted", run: runConfig},
+}
+
+// usageError reports an invalid invocation, it exits with exitUsage
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
 // Swagger Info
 // @title WebForum API
 // @version 1.0
@@ -28,69 +65,122 @@ import (
 // @in header
 // @name Authorization
 // @description JWT token
-// Main function to start the server
+// Main function dispatching to the subcommands, serving the API when none is given
 func main() {
-	ctx := context.Background()
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, relying on environment variables")
+	}
 
-	err := godotenv.Load()
+	// -config FILE before the command selects the optional YAML config file, CONFIG_FILE otherwise
+	args := os.Args[1:]
+	configPath := os.Getenv("CONFIG_FILE")
+	if len(args) > 1 && (args[0] == "-config" || args[0] == "--config") {
+		configPath, args = args[1], args[2:]
+	} else if len(args) > 0 && strings.HasPrefix(args[0], "-config=") {
+		configPath, args = strings.TrimPrefix(args[0], "-config="), args[1:]
+	}
 
-	if err != nil {
-		log.Println("No .env file found, relying on environment variables")
+	name := "serve"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
 	}
 
-	dsn := os.Getenv("DB_DSN")
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		os.Exit(exitOK)
+	}
 
-	if dsn == "" {
-		log.Fatal("DB_DSN environment variable is not set")
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "app %s: invalid configuration: %v\n", name, err)
+			os.Exit(exitFailure)
+		}
+		// Also routes the standard log package through the structured logger
+		slog.SetDefault(cfg.Logger(os.Stderr))
+		os.Exit(exitCode(cmd.name, cmd.run(context.Background(), cfg, args)))
 	}
 
-	jwtSecret := os.Getenv("JWT_SECRET")
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	printUsage()
+	os.Exit(exitUsage)
+}
 
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET environment variable is not set")
+// exitCode reports err on stderr and maps it to the exit status of the process
+func exitCode(name string, err error) int {
+	var usage usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usage):
+		fmt.Fprintf(os.Stderr, "app %s: %v\n", name, err)
+		return exitUsage
+	default:
+		fmt.Fprintf(os.Stderr, "app %s: %v\n", name, err)
+		return exitFailure
 	}
+}
 
-	db, err := database.New(ctx, dsn)
-	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+// printUsage lists the available subcommands
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "usage: app [-config FILE] <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-15s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "run `app <command> -h` for the flags of a command")
+}
 
-	s3Bucket := os.Getenv("AWS_S3_BUCKET")
-	s3Region := os.Getenv("AWS_REGION")
-	s3Client, err := storage.NewS3Client(ctx, s3Bucket, s3Region)
+// newFlagSet creates the flag set of a subcommand
+// Parse errors are returned instead of exiting so they map to exitUsage
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("app "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
 
-	if err != nil {
-		log.Fatalf("failed to create S3 client: %v", err)
+// parseFlags parses args and wraps parse errors into a usageError
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{msg: err.Error()}
 	}
+	return nil
+}
 
-	deps := httpdelivery.RouterDeps{
-		UserRepo:            repository.NewUserRepository(db),
-		TokenRepo:           repository.NewTokenRepository(db),
-		CategoryRepo:        repository.NewCategoryRepository(db),
-		MembershipRepo:      repository.NewMembershipRepository(db),
-		PostRepo:            repository.NewPostRepository(db),
-		ReactionRepo:        repository.NewReactionRepository(db),
-		ReactionTypeRepo:    repository.NewReactionTypeRepository(db),
-		CommentRepo:         repository.NewCommentRepository(db),
-		CommentReactionRepo: repository.NewCommentReactionRepository(db),
-		NotificationRepo:    repository.NewNotificationRepository(db),
-		S3Client:            s3Client,
-		JWTSecret:           jwtSecret,
+// openDB connects to the configured database
+func openDB(ctx context.Context, cfg *config.Config) (*sql.DB, error) {
+	if err := cfg.RequireDatabase(); err != nil {
+		return nil, err
 	}
 
-	r := httpdelivery.Routes(deps)
-
-	// Swagger Ui endpoint for API documentation
-	r.Get("/swagger/*", httpSwagger.WrapHandler)
+	db, err := database.New(ctx, cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
+// runConfig handles `app config print`, writing the effective configuration as YAML
+func runConfig(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return usageError{msg: "expected a subcommand: print"}
+	}
+	if err := parseFlags(newFlagSet("config print"), args[1:]); err != nil {
+		return err
 	}
 
-	addr := ":" + port
-	log.Printf("listening on %s", addr)
-	if err := http.ListenAndServe(addr, r); err != nil {
-		log.Fatalf("server stopped: %v", err)
+	out, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		return err
 	}
+	_, err = os.Stdout.Write(out)
+	return err
 }
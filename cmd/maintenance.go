@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"my-chi-app/internal/config"
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// runCreateAdmin handles `app create-admin`, creating a verified admin account
+// With -promote an existing account matching the username or email is made admin instead
+func runCreateAdmin(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("create-admin")
+	username := fs.String("username", "", "username of the admin (required)")
+	email := fs.String("email", "", "email of the admin (required when creating)")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "password of the admin, at least 8 characters (env ADMIN_PASSWORD)")
+	promote := fs.Bool("promote", false, "promote the existing user with this username or email instead of failing")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *username == "" && *email == "" {
+		return usageError{msg: "-username or -email is required"}
+	}
+
+	db, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	userRepo := repository.NewUserRepository(db)
+
+	existing, err := findUser(ctx, userRepo, *username, *email)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if !*promote {
+			return fmt.Errorf("user %q already exists, pass -promote to make it admin", existing.Username)
+		}
+		if err := userRepo.UpdateRole(ctx, existing.ID, entity.RoleAdmin); err != nil {
+			return fmt.Errorf("failed to promote user: %w", err)
+		}
+		fmt.Printf("promoted user %d (%s) to admin\n", existing.ID, existing.Username)
+		return nil
+	}
+
+	if *username == "" || *email == "" {
+		return usageError{msg: "-username and -email are required to create an admin"}
+	}
+	if len(*password) < 8 {
+		return usageError{msg: "-password must be at least 8 characters"}
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user, err := userRepo.Create(ctx, &entity.User{
+		Username: *username,
+		Email:    *email,
+		Password: string(hashedPassword),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	if err := userRepo.UpdateRole(ctx, user.ID, entity.RoleAdmin); err != nil {
+		return fmt.Errorf("failed to grant admin role: %w", err)
+	}
+	if err := userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	fmt.Printf("created admin %d (%s)\n", user.ID, user.Username)
+	return nil
+}
+
+// findUser looks a user up by username, then by email, returning nil when neither matches
+func findUser(ctx context.Context, userRepo *repository.UserRepository, username, email string) (*entity.User, error) {
+	if username != "" {
+		user, err := userRepo.GetByUsername(ctx, username)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to fetch user: %w", err)
+		}
+	}
+	if email != "" {
+		user, err := userRepo.GetByEmail(ctx, email)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to fetch user: %w", err)
+		}
+	}
+	return nil, nil
+}
+
+// runPurgeTokens handles `app purge-tokens`, deleting sessions and account tokens past their expiry
+func runPurgeTokens(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("purge-tokens")
+	grace := fs.Duration("grace", 0, "keep tokens that expired less than this long ago")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *grace < 0 {
+		return usageError{msg: "-grace must not be negative"}
+	}
+
+	db, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	cutoff := time.Now().Add(-*grace)
+
+	sessions, err := repository.NewTokenRepository(db).PurgeExpired(ctx, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to purge tokens: %w", err)
+	}
+	accountTokens, err := repository.NewAccountTokenRepository(db).PurgeExpired(ctx, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to purge account tokens: %w", err)
+	}
+
+	fmt.Printf("purged %d tokens and %d account tokens\n", sessions, accountTokens)
+	return nil
+}
+
+// runReindexSearch handles `app reindex-search`, rebuilding the full-text search indexes
+func runReindexSearch(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("reindex-search")
+	concurrently := fs.Bool("concurrently", true, "rebuild without blocking writes")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	db, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	start := time.Now()
+	if err := repository.NewSearchRepository(db).Reindex(ctx, *concurrently); err != nil {
+		return fmt.Errorf("failed to reindex search: %w", err)
+	}
+
+	fmt.Printf("reindexed search in %s\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// splitList splits a comma separated flag value, dropping empty entries
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"my-chi-app/internal/config"
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Default demo content created by the seed command
+var (
+	seedCategories    = "General,Announcements,Help,Showcase,Off-topic"
+	seedReactionTypes = "Like,Love,Haha,Wow,Sad,Angry"
+	seedHeadlines     = []string{
+		"Welcome to the forum",
+		"What are you working on this week?",
+		"Tips for getting started",
+		"Show us your setup",
+		"Weekly discussion thread",
+		"Question about the rules",
+		"Favourite resources",
+		"Looking for feedback",
+	}
+	seedTexts = []string{
+		"Curious to hear what everyone thinks about this.",
+		"I have been trying this for a while and finally got it working.",
+		"Does anyone have a good recommendation here?",
+		"Sharing this in case it helps someone else.",
+		"Thanks for all the help so far, this community is great.",
+	}
+)
+
+// runSeed handles `app seed`, filling the database with demo users, categories and content
+// Users, categories and reaction types are reused when they exist, posts and comments are always added
+func runSeed(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("seed")
+	users := fs.Int("users", 10, "number of demo users, named demo1, demo2, ...")
+	posts := fs.Int("posts", 3, "posts per demo user")
+	comments := fs.Int("comments", 3, "comments per post")
+	password := fs.String("password", "password123", "password of the demo users")
+	categories := fs.String("categories", seedCategories, "comma separated categories to create")
+	reactionTypes := fs.String("reaction-types", seedReactionTypes, "comma separated reaction types to create")
+	randSeed := fs.Int64("rand-seed", 1, "seed of the random generator, for reproducible data")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *users < 1 || *posts < 0 || *comments < 0 {
+		return usageError{msg: "-users must be at least 1, -posts and -comments must not be negative"}
+	}
+	if len(*password) < 8 {
+		return usageError{msg: "-password must be at least 8 characters"}
+	}
+	categoryNames := splitList(*categories)
+	if len(categoryNames) == 0 {
+		return usageError{msg: "-categories must name at least one category"}
+	}
+
+	db, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rng := rand.New(rand.NewSource(*randSeed))
+	userRepo := repository.NewUserRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	reactionTypeRepo := repository.NewReactionTypeRepository(db)
+	membershipRepo := repository.NewMembershipRepository(db)
+	postRepo := repository.NewPostRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	reactionRepo := repository.NewReactionRepository(db)
+	commentReactionRepo := repository.NewCommentReactionRepository(db)
+
+	categoryIDs := make([]int64, 0, len(categoryNames))
+	for _, name := range categoryNames {
+		category, err := categoryRepo.GetByName(ctx, name)
+		if errors.Is(err, sql.ErrNoRows) {
+			category, err = categoryRepo.Create(ctx, &entity.Category{Category: name})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to seed category %q: %w", name, err)
+		}
+		categoryIDs = append(categoryIDs, category.ID)
+	}
+
+	reactionTypeIDs, err := seedReactionTypeIDs(ctx, reactionTypeRepo, splitList(*reactionTypes))
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	userIDs := make([]int64, 0, *users)
+	for i := 1; i <= *users; i++ {
+		username := fmt.Sprintf("demo%d", i)
+		user, err := userRepo.GetByUsername(ctx, username)
+		if errors.Is(err, sql.ErrNoRows) {
+			user, err = userRepo.Create(ctx, &entity.User{
+				Username: username,
+				Email:    username + "@example.com",
+				Password: string(hashedPassword),
+			})
+			if err == nil {
+				err = userRepo.MarkEmailVerified(ctx, user.ID)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to seed user %q: %w", username, err)
+		}
+		userIDs = append(userIDs, user.ID)
+
+		for _, categoryID := range categoryIDs {
+			if rng.Intn(2) == 0 {
+				continue
+			}
+			if _, err := membershipRepo.Create(ctx, &entity.Membership{CategoryID: categoryID, UserID: user.ID}); err != nil {
+				return fmt.Errorf("failed to seed membership: %w", err)
+			}
+		}
+	}
+
+	var postCount, commentCount, reactionCount int
+	for _, ownerID := range userIDs {
+		for i := 0; i < *posts; i++ {
+			text := seedTexts[rng.Intn(len(seedTexts))]
+			post, err := postRepo.Create(ctx, &entity.Post{
+				OwnerID:    ownerID,
+				CategoryID: categoryIDs[rng.Intn(len(categoryIDs))],
+				Headline:   seedHeadlines[rng.Intn(len(seedHeadlines))],
+				Text:       &text,
+				Status:     true,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to seed post: %w", err)
+			}
+			postCount++
+
+			var parentID *int64
+			for j := 0; j < *comments; j++ {
+				comment, err := commentRepo.Create(ctx, &entity.Comment{
+					PostID:          post.ID,
+					OwnerID:         userIDs[rng.Intn(len(userIDs))],
+					ParentCommentID: parentID,
+					Text:            seedTexts[rng.Intn(len(seedTexts))],
+					Status:          true,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to seed comment: %w", err)
+				}
+				commentCount++
+
+				// Reply to the previous comment now and then to build threads
+				parentID = nil
+				if rng.Intn(3) == 0 {
+					parentID = &comment.ID
+				}
+
+				if len(reactionTypeIDs) > 0 && rng.Intn(2) == 0 {
+					if _, err := commentReactionRepo.Upsert(ctx, &entity.CommentReaction{
+						CommentID:      comment.ID,
+						OwnerID:        userIDs[rng.Intn(len(userIDs))],
+						ReactionTypeID: reactionTypeIDs[rng.Intn(len(reactionTypeIDs))],
+					}); err != nil {
+						return fmt.Errorf("failed to seed comment reaction: %w", err)
+					}
+					reactionCount++
+				}
+			}
+
+			if len(reactionTypeIDs) == 0 {
+				continue
+			}
+			for _, reactorID := range rng.Perm(len(userIDs))[:rng.Intn(len(userIDs)+1)] {
+				if _, err := reactionRepo.Upsert(ctx, &entity.Reaction{
+					PostID:         post.ID,
+					OwnerID:        userIDs[reactorID],
+					ReactionTypeID: reactionTypeIDs[rng.Intn(len(reactionTypeIDs))],
+				}); err != nil {
+					return fmt.Errorf("failed to seed reaction: %w", err)
+				}
+				reactionCount++
+			}
+		}
+	}
+
+	fmt.Printf("seeded %d users, %d categories, %d posts, %d comments and %d reactions\n",
+		len(userIDs), len(categoryIDs), postCount, commentCount, reactionCount)
+	return nil
+}
+
+// seedReactionTypeIDs creates the missing reaction types and returns the IDs of all given names
+func seedReactionTypeIDs(ctx context.Context, reactionTypeRepo *repository.ReactionTypeRepository, names []string) ([]int64, error) {
+	existing, err := reactionTypeRepo.List(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reaction types: %w", err)
+	}
+	byName := make(map[string]*entity.ReactionType, len(existing))
+	for _, rt := range existing {
+		byName[rt.Name] = rt
+	}
+
+	ids := make([]int64, 0, len(names))
+	for _, name := range names {
+		rt, ok := byName[name]
+		if !ok {
+			rt, err = reactionTypeRepo.Create(ctx, &entity.ReactionType{Name: name})
+			if err != nil {
+				return nil, fmt.Errorf("failed to seed reaction type %q: %w", name, err)
+			}
+		}
+		if rt.RetiredAt == nil {
+			ids = append(ids, rt.ID)
+		}
+	}
+	return ids, nil
+}
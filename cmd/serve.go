@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"my-chi-app/internal/config"
+	"my-chi-app/internal/database"
+	"my-chi-app/internal/database/repository"
+	httpdelivery "my-chi-app/internal/delivery/http"
+	"my-chi-app/internal/mail"
+	"my-chi-app/internal/metrics"
+	"my-chi-app/internal/notification"
+	"my-chi-app/internal/ratelimit"
+	"my-chi-app/internal/storage"
+	"my-chi-app/internal/tracing"
+
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+// runServe handles `app serve`, starting the HTTP API
+func runServe(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("serve")
+	port := fs.String("port", cfg.Server.Port, "port to listen on (env PORT)")
+	migrate := fs.Bool("migrate", cfg.Server.MigrateOnStart, "apply pending migrations before serving (env MIGRATE_ON_START)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if err := cfg.RequireServer(); err != nil {
+		return err
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	// Flushed after the server and the database pool stopped so their last spans are exported
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	db, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if *migrate {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+		slog.Info("applied migrations", "count", len(applied))
+	}
+
+	s3Client, err := storage.NewS3Client(ctx, cfg.Storage.Bucket, cfg.Storage.Region)
+	if err != nil {
+		return fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	// File mailer by default so local setups work without an SMTP server
+	var mailer mail.Mailer
+	switch cfg.Mail.Driver {
+	case "smtp":
+		mailer = mail.NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	default:
+		mailer, err = mail.NewFileMailer(cfg.Mail.Dir, cfg.Mail.From)
+		if err != nil {
+			return fmt.Errorf("failed to create mailer: %w", err)
+		}
+	}
+	// Account emails go out in the background, shutdown waits for the ones still being sent
+	backgroundMailer := mail.NewBackgroundMailer(mailer, 30*time.Second)
+
+	// In-process broker by default, Postgres LISTEN/NOTIFY when running several instances
+	var broker notification.Broker = notification.NewMemoryBroker()
+	if cfg.Notifications.Broker == "postgres" {
+		pgBroker, err := notification.NewPostgresBroker(db, cfg.Database.DSN)
+		if err != nil {
+			return fmt.Errorf("failed to start notification broker: %w", err)
+		}
+		defer pgBroker.Close()
+		broker = pgBroker
+	}
+
+	// In-process buckets by default, Postgres when several instances must share the limits
+	var (
+		rateLimitStore   ratelimit.Store
+		pgRateLimitStore *ratelimit.PostgresStore
+	)
+	if cfg.RateLimit.Enabled {
+		rateLimitStore = ratelimit.NewMemoryStore()
+		if cfg.RateLimit.Store == "postgres" {
+			pgRateLimitStore = ratelimit.NewPostgresStore(db)
+			rateLimitStore = pgRateLimitStore
+		}
+	}
+
+	stopScheduler := startScheduler(ctx, cfg.Scheduler, db, s3Client, pgRateLimitStore)
+	defer stopScheduler()
+
+	// Repository methods are traced and timed, so the observer is set before any repository is used
+	var appMetrics *metrics.Metrics
+	observers := []repository.Observer{tracing.ObserveQuery}
+	if cfg.Metrics.Enabled {
+		appMetrics = metrics.New(db)
+		observers = append(observers, appMetrics.ObserveQuery)
+	}
+	repository.SetObserver(repository.Observers(observers...))
+
+	notificationRepo := repository.NewNotificationRepository(db)
+	lifecycle := httpdelivery.NewLifecycle()
+
+	deps := httpdelivery.RouterDeps{
+		UserRepo:            repository.NewUserRepository(db),
+		TokenRepo:           repository.NewTokenRepository(db),
+		CategoryRepo:        repository.NewCategoryRepository(db),
+		MembershipRepo:      repository.NewMembershipRepository(db),
+		PostRepo:            repository.NewPostRepository(db),
+		ReactionRepo:        repository.NewReactionRepository(db),
+		ReactionTypeRepo:    repository.NewReactionTypeRepository(db),
+		CommentRepo:         repository.NewCommentRepository(db),
+		CommentReactionRepo: repository.NewCommentReactionRepository(db),
+		NotificationRepo:    notificationRepo,
+		ModeratorRepo:       repository.NewModeratorRepository(db),
+		SearchRepo:          repository.NewSearchRepository(db),
+		AccountTokenRepo:    repository.NewAccountTokenRepository(db),
+		Notifier:            notification.NewDispatcher(notificationRepo, broker, appMetrics),
+		NotificationBroker:  broker,
+		S3Client:            s3Client,
+		Mailer:              backgroundMailer,
+		Lifecycle:           lifecycle,
+		Metrics:             appMetrics,
+		ExposeMetrics:       cfg.Metrics.Port == "",
+		RateLimitStore:      rateLimitStore,
+		TrustProxyHeaders:   cfg.Server.TrustProxyHeaders,
+		AppBaseURL:          cfg.Server.AppBaseURL,
+		JWTSecret:           cfg.Auth.JWTSecret,
+		AccessTokenTTL:      cfg.Auth.AccessTokenTTL,
+		RefreshTokenTTL:     cfg.Auth.RefreshTokenTTL,
+		PresignExpiry:       cfg.Storage.PresignExpiry,
+		RateLimits: httpdelivery.RateLimitPolicies{
+			Auth:    cfg.RateLimit.Auth,
+			Post:    cfg.RateLimit.Post,
+			Comment: cfg.RateLimit.Comment,
+			React:   cfg.RateLimit.React,
+			Lockout: ratelimit.Lockout{
+				Threshold: cfg.RateLimit.LoginLockoutThreshold,
+				Base:      cfg.RateLimit.LoginLockoutBase,
+				Max:       cfg.RateLimit.LoginLockoutMax,
+			},
+		},
+		ReadinessChecks: []httpdelivery.ReadinessCheck{
+			{Name: "database", Timeout: cfg.Readiness.DatabaseTimeout, Check: db.PingContext},
+			{Name: "migrations", Timeout: cfg.Readiness.MigrationsTimeout, Check: migrator.CheckCurrent},
+			{Name: "storage", Timeout: cfg.Readiness.StorageTimeout, Check: s3Client.Ping},
+		},
+	}
+
+	r := httpdelivery.Routes(deps)
+
+	// Swagger Ui endpoint for API documentation
+	r.Get("/swagger/*", httpSwagger.WrapHandler)
+
+	srv := &http.Server{
+		Addr:              ":" + *port,
+		Handler:           r,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	sigCtx, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	serveErr := make(chan error, 2)
+	go func() {
+		slog.Info("listening", "addr", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	// Admin server keeping /metrics off the public port
+	var adminSrv *http.Server
+	if appMetrics != nil && cfg.Metrics.Port != "" {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("/metrics", appMetrics.Handler())
+		adminSrv = &http.Server{
+			Addr:              ":" + cfg.Metrics.Port,
+			Handler:           adminMux,
+			ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		}
+		go func() {
+			slog.Info("serving metrics", "addr", adminSrv.Addr)
+			serveErr <- adminSrv.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("server stopped: %w", err)
+	case <-sigCtx.Done():
+	}
+	// A second signal kills the process right away
+	stopSignals()
+
+	// Fail health checks first so load balancers stop routing new requests here
+	slog.Info("shutting down", "drain_delay", cfg.Server.DrainDelay.String())
+	lifecycle.StartDraining()
+	time.Sleep(cfg.Server.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Streams never go idle on their own, end them so Shutdown can finish
+	broker.CloseSessions()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("failed to drain in-flight requests: %w", err)
+	}
+	// No request can queue an email anymore, let the queued ones go out
+	if err := backgroundMailer.Wait(shutdownCtx); err != nil {
+		slog.Warn("stopped waiting for account emails", "error", err)
+	}
+	// Stopped last so the drain stays visible to scrapes
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(shutdownCtx); err != nil {
+			adminSrv.Close()
+		}
+	}
+
+	// Deferred calls then stop the scheduler, the notification broker and the database pool in that order
+	slog.Info("server stopped")
+	return nil
+}
@@ -0,0 +1,484 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+
+	"my-chi-app/internal/ratelimit"
+
+	"gopkg.in/yaml.v2"
+)
+
+// MinJWTSecretLength is the shortest JWT_SECRET accepted, 32 bytes matches the HS256 key size
+const MinJWTSecretLength = 32
+
+// awsRegion matches region names such as us-west-2, eu-central-1 or us-gov-west-1
+var awsRegion = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-\d+$`)
+
+// stringerType is written as a string by redact, such as time.Duration and ratelimit.Limit
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// Config is the typed configuration of the application
+// Values are read from defaults, then the optional config file, then the environment
+// Fields tagged with env are overridden by that environment variable, secret fields are redacted when printed
+type Config struct {
+	Log           LogConfig           `yaml:"log"`
+	Server        ServerConfig        `yaml:"server"`
+	Database      DatabaseConfig      `yaml:"database"`
+	Auth          AuthConfig          `yaml:"auth"`
+	Storage       StorageConfig       `yaml:"storage"`
+	Mail          MailConfig          `yaml:"mail"`
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Scheduler     SchedulerConfig     `yaml:"scheduler"`
+	RateLimit     RateLimitConfig     `yaml:"rate_limit"`
+	Metrics       MetricsConfig       `yaml:"metrics"`
+	Readiness     ReadinessConfig     `yaml:"readiness"`
+	Tracing       TracingConfig       `yaml:"tracing"`
+}
+
+// LogConfig configures the application logs
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+// ServerConfig configures the HTTP server and its shutdown
+type ServerConfig struct {
+	Port              string        `yaml:"port" env:"PORT"`
+	AppBaseURL        string        `yaml:"app_base_url" env:"APP_BASE_URL"`
+	MigrateOnStart    bool          `yaml:"migrate_on_start" env:"MIGRATE_ON_START"`
+	TrustProxyHeaders bool          `yaml:"trust_proxy_headers" env:"TRUST_PROXY_HEADERS"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	DrainDelay        time.Duration `yaml:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+// DatabaseConfig configures the PostgreSQL connection pool
+type DatabaseConfig struct {
+	DSN             string        `yaml:"dsn" env:"DB_DSN" secret:"url"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+}
+
+// AuthConfig configures the session tokens
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+}
+
+// StorageConfig configures the S3 bucket holding uploads
+type StorageConfig struct {
+	Bucket        string        `yaml:"bucket" env:"AWS_S3_BUCKET"`
+	Region        string        `yaml:"region" env:"AWS_REGION"`
+	PresignExpiry time.Duration `yaml:"presign_expiry" env:"S3_PRESIGN_EXPIRY"`
+}
+
+// MailConfig configures how account emails are delivered
+type MailConfig struct {
+	Driver       string `yaml:"driver" env:"MAIL_DRIVER"`
+	From         string `yaml:"from" env:"MAIL_FROM"`
+	Dir          string `yaml:"dir" env:"MAIL_DIR"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// NotificationsConfig configures the real-time notification broker
+type NotificationsConfig struct {
+	Broker string `yaml:"broker" env:"NOTIFICATION_BROKER"`
+}
+
+// SchedulerConfig configures the background maintenance jobs, an interval of 0 disables a job
+type SchedulerConfig struct {
+	Enabled                     bool          `yaml:"enabled" env:"SCHEDULER_ENABLED"`
+	PurgeTokensInterval         time.Duration `yaml:"purge_tokens_interval" env:"JOB_PURGE_TOKENS_INTERVAL"`
+	PurgeTokensJitter           time.Duration `yaml:"purge_tokens_jitter" env:"JOB_PURGE_TOKENS_JITTER"`
+	TokenPurgeGrace             time.Duration `yaml:"token_purge_grace" env:"TOKEN_PURGE_GRACE"`
+	NotificationCleanupInterval time.Duration `yaml:"notification_cleanup_interval" env:"JOB_NOTIFICATION_CLEANUP_INTERVAL"`
+	NotificationCleanupJitter   time.Duration `yaml:"notification_cleanup_jitter" env:"JOB_NOTIFICATION_CLEANUP_JITTER"`
+	NotificationRetention       time.Duration `yaml:"notification_retention" env:"NOTIFICATION_RETENTION"`
+	RankingRefreshInterval      time.Duration `yaml:"ranking_refresh_interval" env:"JOB_RANKING_REFRESH_INTERVAL"`
+	RankingRefreshJitter        time.Duration `yaml:"ranking_refresh_jitter" env:"JOB_RANKING_REFRESH_JITTER"`
+	UploadCleanupInterval       time.Duration `yaml:"upload_cleanup_interval" env:"JOB_UPLOAD_CLEANUP_INTERVAL"`
+	UploadCleanupJitter         time.Duration `yaml:"upload_cleanup_jitter" env:"JOB_UPLOAD_CLEANUP_JITTER"`
+	UploadOrphanAge             time.Duration `yaml:"upload_orphan_age" env:"UPLOAD_ORPHAN_AGE"`
+	RateLimitCleanupInterval    time.Duration `yaml:"rate_limit_cleanup_interval" env:"JOB_RATE_LIMIT_CLEANUP_INTERVAL"`
+	RateLimitCleanupJitter      time.Duration `yaml:"rate_limit_cleanup_jitter" env:"JOB_RATE_LIMIT_CLEANUP_JITTER"`
+}
+
+// RateLimitConfig configures request throttling and the login lockout
+// Limits are written as requests/period such as 10/1m, off disables a limit
+type RateLimitConfig struct {
+	Enabled               bool            `yaml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	Store                 string          `yaml:"store" env:"RATE_LIMIT_STORE"`
+	Auth                  ratelimit.Limit `yaml:"auth" env:"RATE_LIMIT_AUTH"`
+	Post                  ratelimit.Limit `yaml:"post" env:"RATE_LIMIT_POST"`
+	Comment               ratelimit.Limit `yaml:"comment" env:"RATE_LIMIT_COMMENT"`
+	React                 ratelimit.Limit `yaml:"react" env:"RATE_LIMIT_REACT"`
+	LoginLockoutThreshold int             `yaml:"login_lockout_threshold" env:"LOGIN_LOCKOUT_THRESHOLD"`
+	LoginLockoutBase      time.Duration   `yaml:"login_lockout_base" env:"LOGIN_LOCKOUT_BASE"`
+	LoginLockoutMax       time.Duration   `yaml:"login_lockout_max" env:"LOGIN_LOCKOUT_MAX"`
+}
+
+// MetricsConfig configures the Prometheus metrics endpoint
+// Without a port /metrics is served on the API port, otherwise on a separate admin port
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" env:"METRICS_ENABLED"`
+	Port    string `yaml:"port" env:"METRICS_PORT"`
+}
+
+// ReadinessConfig configures how long each dependency may take to answer the readiness probe
+type ReadinessConfig struct {
+	DatabaseTimeout   time.Duration `yaml:"database_timeout" env:"READY_DB_TIMEOUT"`
+	MigrationsTimeout time.Duration `yaml:"migrations_timeout" env:"READY_MIGRATIONS_TIMEOUT"`
+	StorageTimeout    time.Duration `yaml:"storage_timeout" env:"READY_STORAGE_TIMEOUT"`
+}
+
+// TracingConfig configures OpenTelemetry tracing
+// Exporter is otlp, stdout or none, the otlp exporter also honours the standard OTEL_EXPORTER_OTLP_* variables
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_OTLP_ENDPOINT" secret:"url"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// Default returns the configuration used when nothing overrides it
+func Default() *Config {
+	return &Config{
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Server: ServerConfig{
+			Port:              "3000",
+			AppBaseURL:        "http://localhost:5173",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			DrainDelay:        5 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    20,
+			MaxIdleConns:    10,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnMaxLifetime: time.Hour,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  24 * time.Hour,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+		Storage: StorageConfig{
+			PresignExpiry: 15 * time.Minute,
+		},
+		Mail: MailConfig{
+			Driver:   "file",
+			From:     "no-reply@localhost",
+			Dir:      "mail",
+			SMTPPort: 587,
+		},
+		Notifications: NotificationsConfig{
+			Broker: "memory",
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:                     true,
+			PurgeTokensInterval:         time.Hour,
+			PurgeTokensJitter:           5 * time.Minute,
+			NotificationCleanupInterval: 6 * time.Hour,
+			NotificationCleanupJitter:   10 * time.Minute,
+			NotificationRetention:       30 * 24 * time.Hour,
+			RankingRefreshInterval:      15 * time.Minute,
+			RankingRefreshJitter:        time.Minute,
+			UploadCleanupInterval:       24 * time.Hour,
+			UploadCleanupJitter:         30 * time.Minute,
+			UploadOrphanAge:             24 * time.Hour,
+			RateLimitCleanupInterval:    time.Hour,
+			RateLimitCleanupJitter:      5 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:               true,
+			Store:                 "memory",
+			Auth:                  ratelimit.Limit{Burst: 10, Period: time.Minute},
+			Post:                  ratelimit.Limit{Burst: 5, Period: time.Minute},
+			Comment:               ratelimit.Limit{Burst: 20, Period: time.Minute},
+			React:                 ratelimit.Limit{Burst: 60, Period: time.Minute},
+			LoginLockoutThreshold: 5,
+			LoginLockoutBase:      30 * time.Second,
+			LoginLockoutMax:       time.Hour,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Readiness: ReadinessConfig{
+			DatabaseTimeout:   time.Second,
+			MigrationsTimeout: 2 * time.Second,
+			StorageTimeout:    3 * time.Second,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "webforum",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file at path when not empty and the environment
+// The result is validated, see Validate
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+		if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+			return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the values that are set, settings only some commands need are checked by the Require methods
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "LOG_LEVEL must be debug, info, warn or error, got %q", c.Log.Level)
+	check(c.Log.Format == "json" || c.Log.Format == "text", "LOG_FORMAT must be json or text, got %q", c.Log.Format)
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be a port number, got %q", c.Server.Port))
+	}
+	check(c.Server.ReadHeaderTimeout > 0, "HTTP_READ_HEADER_TIMEOUT must be positive")
+	check(c.Server.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
+
+	check(c.Database.MaxOpenConns > 0, "DB_MAX_OPEN_CONNS must be positive")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS")
+
+	if c.Auth.JWTSecret != "" {
+		check(len(c.Auth.JWTSecret) >= MinJWTSecretLength, "JWT_SECRET must be at least %d characters", MinJWTSecretLength)
+	}
+	check(c.Auth.AccessTokenTTL > 0, "ACCESS_TOKEN_TTL must be positive")
+	check(c.Auth.RefreshTokenTTL >= c.Auth.AccessTokenTTL, "REFRESH_TOKEN_TTL must not be shorter than ACCESS_TOKEN_TTL")
+
+	if c.Storage.Region != "" {
+		check(awsRegion.MatchString(c.Storage.Region), "AWS_REGION %q is not a valid region", c.Storage.Region)
+	}
+	check(c.Storage.PresignExpiry > 0 && c.Storage.PresignExpiry <= 7*24*time.Hour, "S3_PRESIGN_EXPIRY must be between 1s and 168h")
+
+	switch c.Mail.Driver {
+	case "file":
+		check(c.Mail.Dir != "", "MAIL_DIR is required with the file mail driver")
+	case "smtp":
+		check(c.Mail.SMTPHost != "", "SMTP_HOST is required with the smtp mail driver")
+		check(c.Mail.SMTPPort > 0 && c.Mail.SMTPPort <= 65535, "SMTP_PORT must be a port number")
+	default:
+		errs = append(errs, fmt.Errorf("MAIL_DRIVER must be file or smtp, got %q", c.Mail.Driver))
+	}
+
+	check(c.Notifications.Broker == "memory" || c.Notifications.Broker == "postgres",
+		"NOTIFICATION_BROKER must be memory or postgres, got %q", c.Notifications.Broker)
+
+	check(c.RateLimit.Store == "memory" || c.RateLimit.Store == "postgres",
+		"RATE_LIMIT_STORE must be memory or postgres, got %q", c.RateLimit.Store)
+	check(c.RateLimit.LoginLockoutThreshold >= 0, "LOGIN_LOCKOUT_THRESHOLD must not be negative")
+	if c.RateLimit.LoginLockoutThreshold > 0 {
+		check(c.RateLimit.LoginLockoutBase > 0, "LOGIN_LOCKOUT_BASE must be positive")
+		check(c.RateLimit.LoginLockoutMax >= c.RateLimit.LoginLockoutBase, "LOGIN_LOCKOUT_MAX must not be shorter than LOGIN_LOCKOUT_BASE")
+	}
+
+	if c.Metrics.Port != "" {
+		if port, err := strconv.Atoi(c.Metrics.Port); err != nil || port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("METRICS_PORT must be a port number, got %q", c.Metrics.Port))
+		}
+		check(c.Metrics.Port != c.Server.Port, "METRICS_PORT must differ from PORT")
+	}
+
+	check(c.Readiness.DatabaseTimeout > 0, "READY_DB_TIMEOUT must be positive")
+	check(c.Readiness.MigrationsTimeout > 0, "READY_MIGRATIONS_TIMEOUT must be positive")
+	check(c.Readiness.StorageTimeout > 0, "READY_STORAGE_TIMEOUT must be positive")
+
+	check(c.Tracing.Exporter == "otlp" || c.Tracing.Exporter == "stdout" || c.Tracing.Exporter == "none",
+		"TRACING_EXPORTER must be otlp, stdout or none, got %q", c.Tracing.Exporter)
+	if c.Tracing.Exporter != "none" {
+		check(c.Tracing.ServiceName != "", "TRACING_SERVICE_NAME is required when tracing is enabled")
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+
+	return errors.Join(errs...)
+}
+
+// RequireDatabase checks the settings needed to connect to the database
+func (c *Config) RequireDatabase() error {
+	if c.Database.DSN == "" {
+		return errors.New("DB_DSN is not set")
+	}
+	return nil
+}
+
+// RequireServer checks the settings needed to serve the API
+func (c *Config) RequireServer() error {
+	var errs []error
+	if err := c.RequireDatabase(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Auth.JWTSecret == "" {
+		errs = append(errs, errors.New("JWT_SECRET is not set"))
+	}
+	if c.Storage.Bucket == "" {
+		errs = append(errs, errors.New("AWS_S3_BUCKET is not set"))
+	}
+	if c.Storage.Region == "" {
+		errs = append(errs, errors.New("AWS_REGION is not set"))
+	}
+	return errors.Join(errs...)
+}
+
+// Logger creates the logger configured by the Log section
+func (c *Config) Logger(w io.Writer) *slog.Logger {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(c.Log.Level))
+
+	opts := &slog.HandlerOptions{Level: level}
+	if c.Log.Format == "text" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+// Redacted returns the configuration as ordered YAML-ready values with secrets hidden
+func (c *Config) Redacted() yaml.MapSlice {
+	return redact(reflect.ValueOf(c).Elem())
+}
+
+// applyEnv overrides the fields tagged with env by the variables that are set
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Tag.Get("env") == "" {
+			if err := applyEnv(fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		raw, ok := os.LookupEnv(name)
+		if name == "" || !ok || raw == "" {
+			continue
+		}
+
+		if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			if err := u.UnmarshalText([]byte(raw)); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+
+		switch {
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			d, err := time.ParseDuration(raw)
+			if err != nil || d < 0 {
+				return fmt.Errorf("%s must be a duration such as 30s or 1h, got %q", name, raw)
+			}
+			fv.SetInt(int64(d))
+		case field.Type.Kind() == reflect.String:
+			fv.SetString(raw)
+		case field.Type.Kind() == reflect.Int:
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				return fmt.Errorf("%s must be an integer, got %q", name, raw)
+			}
+			fv.SetInt(int64(n))
+		case field.Type.Kind() == reflect.Float64:
+			f, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return fmt.Errorf("%s must be a number, got %q", name, raw)
+			}
+			fv.SetFloat(f)
+		case field.Type.Kind() == reflect.Bool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false, got %q", name, raw)
+			}
+			fv.SetBool(b)
+		default:
+			return fmt.Errorf("unsupported config field type %s for %s", field.Type, name)
+		}
+	}
+	return nil
+}
+
+// redact converts a config struct to ordered YAML values, durations and limits are written as strings
+// Fields tagged secret:"true" are hidden entirely, secret:"url" only hides the password of the URL
+func redact(v reflect.Value) yaml.MapSlice {
+	t := v.Type()
+	out := make(yaml.MapSlice, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		key := field.Tag.Get("yaml")
+
+		var value any
+		switch {
+		case field.Type.Implements(stringerType):
+			value = fv.Interface().(fmt.Stringer).String()
+		case field.Type.Kind() == reflect.Struct:
+			value = redact(fv)
+		default:
+			value = fv.Interface()
+		}
+
+		if s, ok := value.(string); ok && s != "" {
+			switch field.Tag.Get("secret") {
+			case "true":
+				value = "[REDACTED]"
+			case "url":
+				value = redactURL(s)
+			}
+		}
+		out = append(out, yaml.MapItem{Key: key, Value: value})
+	}
+	return out
+}
+
+// redactURL hides the password of a URL, other strings such as key=value DSNs are hidden entirely
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "[REDACTED]"
+	}
+	return u.Redacted()
+}
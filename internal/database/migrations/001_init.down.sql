@@ -0,0 +1,13 @@
+-- Revert the initial schema for WebForum
+-- PostgreSQL dialect
+
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS memberships;
+DROP TABLE IF EXISTS comment_reactions;
+DROP TABLE IF EXISTS reactions;
+DROP TABLE IF EXISTS reaction_types;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS tokens;
+DROP TABLE IF EXISTS users;
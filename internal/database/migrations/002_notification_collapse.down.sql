@@ -0,0 +1,4 @@
+-- Revert the collapse index of reaction notifications
+-- PostgreSQL dialect
+
+DROP INDEX IF EXISTS idx_notifications_collapse;
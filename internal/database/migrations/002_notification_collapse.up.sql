@@ -0,0 +1,6 @@
+-- Notifications: reaction notifications are collapsed per actor, enforce it so concurrent reactions cannot both insert one
+-- PostgreSQL dialect
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_collapse
+    ON notifications(owner_id, actor_id, component_type, component_id, notification_type)
+    WHERE notification_type = 'reaction';
@@ -0,0 +1,6 @@
+-- Revert roles
+-- PostgreSQL dialect
+
+DROP TABLE IF EXISTS category_moderators;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
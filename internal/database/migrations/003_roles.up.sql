@@ -0,0 +1,18 @@
+-- Roles: global admin and per-category moderators
+-- Promote the first admin with: UPDATE users SET role = 'admin' WHERE username = '<name>';
+-- PostgreSQL dialect
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
+-- Only the roles known to the application, RequireRole would silently deny anything else
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'admin'));
+
+CREATE TABLE IF NOT EXISTS category_moderators (
+    moderator_id BIGSERIAL PRIMARY KEY,
+    category_id  BIGINT NOT NULL REFERENCES categories(category_id) ON DELETE CASCADE,
+    user_id      BIGINT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT category_moderators_unique_user_category UNIQUE (category_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_moderators_user ON category_moderators(user_id);
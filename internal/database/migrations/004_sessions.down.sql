@@ -0,0 +1,14 @@
+-- Revert sessions
+-- PostgreSQL dialect
+
+DROP INDEX IF EXISTS idx_tokens_session;
+
+ALTER TABLE tokens DROP COLUMN IF EXISTS rotated_at;
+ALTER TABLE tokens DROP COLUMN IF EXISTS session_created_at;
+ALTER TABLE tokens DROP COLUMN IF EXISTS last_used_at;
+ALTER TABLE tokens DROP COLUMN IF EXISTS created_at;
+ALTER TABLE tokens DROP COLUMN IF EXISTS ip_address;
+ALTER TABLE tokens DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE tokens DROP COLUMN IF EXISTS refresh_expires_at;
+ALTER TABLE tokens DROP COLUMN IF EXISTS refresh_token_hash;
+ALTER TABLE tokens DROP COLUMN IF EXISTS session_id;
@@ -0,0 +1,14 @@
+-- Sessions: refresh tokens with rotation and device metadata
+-- PostgreSQL dialect
+
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS session_id VARCHAR(64) NOT NULL DEFAULT md5(random()::text || clock_timestamp()::text);
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS refresh_token_hash VARCHAR(64) UNIQUE;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS refresh_expires_at TIMESTAMPTZ;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS user_agent VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS ip_address VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS session_created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_tokens_session ON tokens(session_id);
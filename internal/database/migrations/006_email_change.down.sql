@@ -0,0 +1,4 @@
+-- Revert email change
+-- PostgreSQL dialect
+
+ALTER TABLE account_tokens DROP COLUMN IF EXISTS payload;
@@ -0,0 +1,4 @@
+-- Email change: pending address carried by the confirmation token
+-- PostgreSQL dialect
+
+ALTER TABLE account_tokens ADD COLUMN IF NOT EXISTS payload VARCHAR(255);
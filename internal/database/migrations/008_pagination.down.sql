@@ -0,0 +1,11 @@
+-- Revert keyset pagination indexes
+-- PostgreSQL dialect
+
+DROP INDEX IF EXISTS idx_notifications_owner_status_created;
+DROP INDEX IF EXISTS idx_notifications_owner_created;
+DROP INDEX IF EXISTS idx_comments_owner_created;
+DROP INDEX IF EXISTS idx_comments_parent_created;
+DROP INDEX IF EXISTS idx_comments_post_created;
+DROP INDEX IF EXISTS idx_posts_owner_created;
+DROP INDEX IF EXISTS idx_posts_category_created;
+DROP INDEX IF EXISTS idx_posts_created;
@@ -0,0 +1,11 @@
+-- Indexes backing keyset pagination on (created_at, id)
+-- PostgreSQL dialect
+
+CREATE INDEX IF NOT EXISTS idx_posts_created ON posts(created_at DESC, post_id DESC);
+CREATE INDEX IF NOT EXISTS idx_posts_category_created ON posts(category_id, created_at DESC, post_id DESC);
+CREATE INDEX IF NOT EXISTS idx_posts_owner_created ON posts(owner_id, created_at DESC, post_id DESC);
+CREATE INDEX IF NOT EXISTS idx_comments_post_created ON comments(post_id, created_at, comment_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_created ON comments(parent_comment_id, created_at, comment_id);
+CREATE INDEX IF NOT EXISTS idx_comments_owner_created ON comments(owner_id, created_at DESC, comment_id DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_owner_created ON notifications(owner_id, created_at DESC, notification_id DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_owner_status_created ON notifications(owner_id, status, created_at DESC, notification_id DESC);
@@ -0,0 +1,13 @@
+-- Revert post ranking counters
+-- PostgreSQL dialect
+
+DROP TRIGGER IF EXISTS trg_post_stats_comment ON comments;
+DROP TRIGGER IF EXISTS trg_post_stats_reaction ON reactions;
+DROP TRIGGER IF EXISTS trg_post_stats_post ON posts;
+
+DROP FUNCTION IF EXISTS post_stats_on_comment();
+DROP FUNCTION IF EXISTS post_stats_on_reaction();
+DROP FUNCTION IF EXISTS post_stats_on_post();
+DROP FUNCTION IF EXISTS post_hot_score(BIGINT, TIMESTAMPTZ);
+
+DROP TABLE IF EXISTS post_stats;
@@ -0,0 +1,95 @@
+-- Post ranking counters for sorted feeds, kept up to date by triggers
+-- PostgreSQL dialect
+
+CREATE TABLE IF NOT EXISTS post_stats (
+    post_id          BIGINT PRIMARY KEY REFERENCES posts(post_id) ON DELETE CASCADE,
+    reaction_count   BIGINT NOT NULL DEFAULT 0,
+    comment_count    BIGINT NOT NULL DEFAULT 0,
+    hot_score        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    last_activity_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Each tenfold increase in reactions is worth 12.5 hours of recency
+CREATE OR REPLACE FUNCTION post_hot_score(reactions BIGINT, created TIMESTAMPTZ) RETURNS DOUBLE PRECISION AS $$
+    SELECT log(greatest(reactions, 1)::float8) + extract(epoch FROM created)::float8 / 45000
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION post_stats_on_post() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO post_stats (post_id, hot_score, last_activity_at)
+    VALUES (NEW.post_id, post_hot_score(0, NEW.created_at), NEW.created_at)
+    ON CONFLICT (post_id) DO NOTHING;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION post_stats_on_reaction() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE post_stats s
+        SET reaction_count = s.reaction_count + 1,
+            hot_score = post_hot_score(s.reaction_count + 1, p.created_at)
+        FROM posts p
+        WHERE s.post_id = NEW.post_id AND p.post_id = NEW.post_id;
+        RETURN NEW;
+    END IF;
+
+    UPDATE post_stats s
+    SET reaction_count = greatest(s.reaction_count - 1, 0),
+        hot_score = post_hot_score(greatest(s.reaction_count - 1, 0), p.created_at)
+    FROM posts p
+    WHERE s.post_id = OLD.post_id AND p.post_id = OLD.post_id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION post_stats_on_comment() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE post_stats
+        SET comment_count = comment_count + 1,
+            last_activity_at = greatest(last_activity_at, NEW.created_at)
+        WHERE post_id = NEW.post_id;
+        RETURN NEW;
+    END IF;
+
+    UPDATE post_stats s
+    SET comment_count = greatest(s.comment_count - 1, 0),
+        last_activity_at = greatest(p.created_at, (SELECT MAX(c.created_at) FROM comments c WHERE c.post_id = OLD.post_id))
+    FROM posts p
+    WHERE s.post_id = OLD.post_id AND p.post_id = OLD.post_id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_post_stats_post ON posts;
+CREATE TRIGGER trg_post_stats_post AFTER INSERT ON posts
+    FOR EACH ROW EXECUTE FUNCTION post_stats_on_post();
+
+DROP TRIGGER IF EXISTS trg_post_stats_reaction ON reactions;
+CREATE TRIGGER trg_post_stats_reaction AFTER INSERT OR DELETE ON reactions
+    FOR EACH ROW EXECUTE FUNCTION post_stats_on_reaction();
+
+DROP TRIGGER IF EXISTS trg_post_stats_comment ON comments;
+CREATE TRIGGER trg_post_stats_comment AFTER INSERT OR DELETE ON comments
+    FOR EACH ROW EXECUTE FUNCTION post_stats_on_comment();
+
+-- Backfill existing posts
+INSERT INTO post_stats (post_id, reaction_count, comment_count, hot_score, last_activity_at)
+SELECT p.post_id,
+       COALESCE(r.total, 0),
+       COALESCE(c.total, 0),
+       post_hot_score(COALESCE(r.total, 0), p.created_at),
+       greatest(p.created_at, c.latest)
+FROM posts p
+LEFT JOIN (SELECT post_id, COUNT(*) AS total FROM reactions GROUP BY post_id) r ON r.post_id = p.post_id
+LEFT JOIN (SELECT post_id, COUNT(*) AS total, MAX(created_at) AS latest FROM comments GROUP BY post_id) c ON c.post_id = p.post_id
+ON CONFLICT (post_id) DO UPDATE
+SET reaction_count = EXCLUDED.reaction_count,
+    comment_count = EXCLUDED.comment_count,
+    hot_score = EXCLUDED.hot_score,
+    last_activity_at = EXCLUDED.last_activity_at;
+
+CREATE INDEX IF NOT EXISTS idx_post_stats_hot ON post_stats(hot_score DESC, post_id DESC);
+CREATE INDEX IF NOT EXISTS idx_post_stats_top ON post_stats(reaction_count DESC, post_id DESC);
+CREATE INDEX IF NOT EXISTS idx_post_stats_active ON post_stats(last_activity_at DESC, post_id DESC);
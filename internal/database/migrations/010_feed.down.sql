@@ -0,0 +1,4 @@
+-- Revert home feed index
+-- PostgreSQL dialect
+
+DROP INDEX IF EXISTS idx_memberships_user;
@@ -0,0 +1,4 @@
+-- Home feed: look up the subscriptions of a user
+-- PostgreSQL dialect
+
+CREATE INDEX IF NOT EXISTS idx_memberships_user ON memberships(user_id);
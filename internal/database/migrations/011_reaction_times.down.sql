@@ -0,0 +1,8 @@
+-- Revert reaction times
+-- PostgreSQL dialect
+
+DROP INDEX IF EXISTS idx_comment_reactions_comment_created;
+DROP INDEX IF EXISTS idx_reactions_post_created;
+
+ALTER TABLE comment_reactions DROP COLUMN IF EXISTS created_at;
+ALTER TABLE reactions DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,8 @@
+-- Reaction times for listing who reacted, newest first
+-- PostgreSQL dialect
+
+ALTER TABLE reactions ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE comment_reactions ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_reactions_post_created ON reactions(post_id, created_at DESC, reaction_id DESC);
+CREATE INDEX IF NOT EXISTS idx_comment_reactions_comment_created ON comment_reactions(comment_id, created_at DESC, comment_reaction_id DESC);
@@ -0,0 +1,4 @@
+-- Revert reaction type retirement
+-- PostgreSQL dialect
+
+ALTER TABLE reaction_types DROP COLUMN IF EXISTS retired_at;
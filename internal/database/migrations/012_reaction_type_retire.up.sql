@@ -0,0 +1,4 @@
+-- Retired reaction types are hidden from new reactions but keep existing reactions valid
+-- PostgreSQL dialect
+
+ALTER TABLE reaction_types ADD COLUMN IF NOT EXISTS retired_at TIMESTAMPTZ;
@@ -0,0 +1,5 @@
+-- Revert rate limiting tables
+-- PostgreSQL dialect
+
+DROP TABLE IF EXISTS login_failures;
+DROP TABLE IF EXISTS rate_limit_buckets;
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"my-chi-app/internal/domain/entity"
+)
+
+// AccountTokenRepository manages single-use email verification and password reset tokens
+type AccountTokenRepository struct {
+	db *sql.DB
+}
+
+// NewAccountTokenRepository creates a new AccountTokenRepository
+func NewAccountTokenRepository(db *sql.DB) *AccountTokenRepository {
+	return &AccountTokenRepository{db: db}
+}
+
+// Create inserts a new account token and invalidates older unused tokens of the same purpose
+func (r *AccountTokenRepository) Create(ctx context.Context, t *entity.AccountToken) (_ *entity.AccountToken, err error) {
+	defer observe(ctx, "AccountTokenRepository", "Create")(&err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	const invalidate = `
+        UPDATE account_tokens SET used_at = NOW()
+        WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+    `
+	if _, err := tx.ExecContext(ctx, invalidate, t.UserID, t.Purpose); err != nil {
+		return nil, err
+	}
+
+	const q = `
+        INSERT INTO account_tokens (user_id, purpose, token_hash, payload, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING account_token_id, created_at
+    `
+	if err := tx.QueryRowContext(ctx, q, t.UserID, t.Purpose, t.TokenHash, t.Payload, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Consume marks a valid token as used and returns it
+// Returns sql.ErrNoRows if the token does not exist, has expired or was already used
+func (r *AccountTokenRepository) Consume(ctx context.Context, purpose, tokenHash string) (_ *entity.AccountToken, err error) {
+	defer observe(ctx, "AccountTokenRepository", "Consume")(&err)
+
+	const q = `
+        UPDATE account_tokens
+        SET used_at = NOW()
+        WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+        RETURNING account_token_id, user_id, purpose, token_hash, payload, expires_at, used_at, created_at
+    `
+	row := r.db.QueryRowContext(ctx, q, tokenHash, purpose)
+	return scanAccountToken(row)
+}
+
+// PurgeExpired deletes all tokens that were used or expired before the cutoff time
+func (r *AccountTokenRepository) PurgeExpired(ctx context.Context, cutoff time.Time) (_ int64, err error) {
+	defer observe(ctx, "AccountTokenRepository", "PurgeExpired")(&err)
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM account_tokens WHERE COALESCE(used_at, expires_at) < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// accountTokenRowScanner defines the interface for scanning account token rows
+type accountTokenRowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanAccountToken scans an account token from the given row scanner
+func scanAccountToken(rs accountTokenRowScanner) (*entity.AccountToken, error) {
+	var (
+		t       entity.AccountToken
+		payload sql.NullString
+		used    sql.NullTime
+	)
+	if err := rs.Scan(&t.ID, &t.UserID, &t.Purpose, &t.TokenHash, &payload, &t.ExpiresAt, &used, &t.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	if payload.Valid {
+		t.Payload = &payload.String
+	}
+	if used.Valid {
+		t.UsedAt = &used.Time
+	}
+	return &t, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"my-chi-app/internal/domain/entity"
+
+	"github.com/lib/pq"
+)
+
+// queryReactionTypes runs a query selecting reaction type columns followed by the ID they belong to
+// It backs the per-viewer reaction lookups of posts and comments
+func queryReactionTypes(ctx context.Context, db *sql.DB, q string, ownerID int64, ids []int64) (map[int64]*entity.ReactionType, error) {
+	rows, err := db.QueryContext(ctx, q, ownerID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := make(map[int64]*entity.ReactionType, len(ids))
+	for rows.Next() {
+		var id int64
+		rt, err := scanReactionType(extraColumnsScanner{rs: rows, extra: []any{&id}})
+		if err != nil {
+			return nil, err
+		}
+		types[id] = rt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// queryReactionCounts runs a query selecting reaction type columns followed by the ID they belong to and a count
+// Each ID maps to its counts in the order the query returns them
+func queryReactionCounts(ctx context.Context, db *sql.DB, q string, ids []int64) (map[int64][]*ReactionTypeCount, error) {
+	rows, err := db.QueryContext(ctx, q, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int64][]*ReactionTypeCount, len(ids))
+	for rows.Next() {
+		var (
+			id    int64
+			count ReactionTypeCount
+		)
+		rt, err := scanReactionType(extraColumnsScanner{rs: rows, extra: []any{&id, &count.Count}})
+		if err != nil {
+			return nil, err
+		}
+		count.Type = rt
+		counts[id] = append(counts[id], &count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// queryReactors runs a query selecting the reaction ID, user ID, username, profile picture,
+// reaction type columns and reaction time of each reactor
+func queryReactors(ctx context.Context, db *sql.DB, q string, args ...any) ([]*Reactor, error) {
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Reactor
+	for rows.Next() {
+		var (
+			rr      Reactor
+			rt      entity.ReactionType
+			image   sql.NullString
+			profile sql.NullString
+		)
+		if err := rows.Scan(&rr.ReactionID, &rr.UserID, &rr.Username, &profile, &rt.ID, &rt.Name, &image, &rr.CreatedAt); err != nil {
+			return nil, err
+		}
+		if profile.Valid {
+			rr.ProfilePicture = &profile.String
+		}
+		if image.Valid {
+			rt.Image = &image.String
+		}
+		rr.Type = &rt
+		list = append(list, &rr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// extraColumnsScanner scans columns selected after the ones a scan function knows about
+type extraColumnsScanner struct {
+	rs    rowScanner
+	extra []any
+}
+
+// Scan appends the extra destinations to the given ones
+func (s extraColumnsScanner) Scan(dest ...any) error {
+	return s.rs.Scan(append(dest, s.extra...)...)
+}
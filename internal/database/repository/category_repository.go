@@ -19,14 +19,16 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 }
 
 // Create inserts a new category into the database
-func (r *CategoryRepository) Create(ctx context.Context, c *entity.Category) (*entity.Category, error) {
+func (r *CategoryRepository) Create(ctx context.Context, c *entity.Category) (_ *entity.Category, err error) {
+	defer observe(ctx, "CategoryRepository", "Create")(&err)
+
 	const q = `
         INSERT INTO categories (category)
         VALUES ($1)
         RETURNING category_id
     `
 
-	err := r.db.QueryRowContext(ctx, q, c.Category).Scan(&c.ID)
+	err = r.db.QueryRowContext(ctx, q, c.Category).Scan(&c.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +36,9 @@ func (r *CategoryRepository) Create(ctx context.Context, c *entity.Category) (*e
 }
 
 // GetByID returns a category by ID
-func (r *CategoryRepository) GetByID(ctx context.Context, id int64) (*entity.Category, error) {
+func (r *CategoryRepository) GetByID(ctx context.Context, id int64) (_ *entity.Category, err error) {
+	defer observe(ctx, "CategoryRepository", "GetByID")(&err)
+
 	const q = `
         SELECT category_id, category
         FROM categories
@@ -45,7 +49,9 @@ func (r *CategoryRepository) GetByID(ctx context.Context, id int64) (*entity.Cat
 }
 
 // List returns all categories
-func (r *CategoryRepository) List(ctx context.Context) ([]*entity.Category, error) {
+func (r *CategoryRepository) List(ctx context.Context) (_ []*entity.Category, err error) {
+	defer observe(ctx, "CategoryRepository", "List")(&err)
+
 	rows, err := r.db.QueryContext(ctx, `SELECT category_id, category FROM categories ORDER BY category`)
 	if err != nil {
 		return nil, err
@@ -67,7 +73,9 @@ func (r *CategoryRepository) List(ctx context.Context) ([]*entity.Category, erro
 }
 
 // GetByName returns a category by name.
-func (r *CategoryRepository) GetByName(ctx context.Context, name string) (*entity.Category, error) {
+func (r *CategoryRepository) GetByName(ctx context.Context, name string) (_ *entity.Category, err error) {
+	defer observe(ctx, "CategoryRepository", "GetByName")(&err)
+
 	const q = `
         SELECT category_id, category
         FROM categories
@@ -19,15 +19,19 @@ func NewCommentReactionRepository(db *sql.DB) *CommentReactionRepository {
 }
 
 // Upsert sets a reaction for a comment by user, updating it if it already exists
-func (r *CommentReactionRepository) Upsert(ctx context.Context, rec *entity.CommentReaction) (*entity.CommentReaction, error) {
+// Changing the reaction type resets created_at, so reactor lists show when the current reaction was made
+func (r *CommentReactionRepository) Upsert(ctx context.Context, rec *entity.CommentReaction) (_ *entity.CommentReaction, err error) {
+	defer observe(ctx, "CommentReactionRepository", "Upsert")(&err)
+
 	const q = `
         INSERT INTO comment_reactions (comment_id, owner_id, reaction_type_id)
         VALUES ($1, $2, $3)
         ON CONFLICT (comment_id, owner_id)
-        DO UPDATE SET reaction_type_id = EXCLUDED.reaction_type_id
+        DO UPDATE SET reaction_type_id = EXCLUDED.reaction_type_id,
+                      created_at = CASE WHEN comment_reactions.reaction_type_id = EXCLUDED.reaction_type_id THEN comment_reactions.created_at ELSE NOW() END
         RETURNING comment_reaction_id
     `
-	err := r.db.QueryRowContext(ctx, q, rec.CommentID, rec.OwnerID, rec.ReactionTypeID).Scan(&rec.ID)
+	err = r.db.QueryRowContext(ctx, q, rec.CommentID, rec.OwnerID, rec.ReactionTypeID).Scan(&rec.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -35,7 +39,9 @@ func (r *CommentReactionRepository) Upsert(ctx context.Context, rec *entity.Comm
 }
 
 // GetByOwnerAndComment retrieves a reaction by user and comment IDs
-func (r *CommentReactionRepository) GetByOwnerAndComment(ctx context.Context, ownerID, commentID int64) (*entity.CommentReaction, error) {
+func (r *CommentReactionRepository) GetByOwnerAndComment(ctx context.Context, ownerID, commentID int64) (_ *entity.CommentReaction, err error) {
+	defer observe(ctx, "CommentReactionRepository", "GetByOwnerAndComment")(&err)
+
 	const q = `
         SELECT comment_reaction_id, comment_id, owner_id, reaction_type_id
         FROM comment_reactions
@@ -45,23 +51,95 @@ func (r *CommentReactionRepository) GetByOwnerAndComment(ctx context.Context, ow
 	return scanCommentReaction(row)
 }
 
+// DeleteByOwnerAndComment removes the reaction of a user on a comment
+func (r *CommentReactionRepository) DeleteByOwnerAndComment(ctx context.Context, ownerID, commentID int64) (err error) {
+	defer observe(ctx, "CommentReactionRepository", "DeleteByOwnerAndComment")(&err)
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM comment_reactions WHERE owner_id = $1 AND comment_id = $2`, ownerID, commentID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // Count returns the total number of reactions on a comment
-func (r *CommentReactionRepository) Count(ctx context.Context, commentID int64) (int64, error) {
+func (r *CommentReactionRepository) Count(ctx context.Context, commentID int64) (_ int64, err error) {
+	defer observe(ctx, "CommentReactionRepository", "Count")(&err)
+
 	const q = `
         SELECT COUNT(comment_reaction_id)
         FROM comment_reactions
         WHERE comment_id = $1
     `
 	var count int64
-	err := r.db.QueryRowContext(ctx, q, commentID).Scan(&count)
+	err = r.db.QueryRowContext(ctx, q, commentID).Scan(&count)
 	if err != nil && err != sql.ErrNoRows {
 		return 0, err
 	}
 	return count, nil
 }
 
+// CountTypesByComments counts the reactions of several comments per reaction type in one query
+// Counts are ordered from the most used type, comments without reactions are missing from the map
+func (r *CommentReactionRepository) CountTypesByComments(ctx context.Context, commentIDs []int64) (_ map[int64][]*ReactionTypeCount, err error) {
+	defer observe(ctx, "CommentReactionRepository", "CountTypesByComments")(&err)
+
+	const q = `
+        SELECT rt.reaction_type_id, rt.name, rt.image, cr.comment_id, COUNT(*)
+        FROM comment_reactions cr
+        INNER JOIN reaction_types rt ON rt.reaction_type_id = cr.reaction_type_id
+        WHERE cr.comment_id = ANY($1)
+        GROUP BY cr.comment_id, rt.reaction_type_id, rt.name, rt.image
+        ORDER BY cr.comment_id, COUNT(*) DESC, rt.reaction_type_id
+    `
+	return queryReactionCounts(ctx, r.db, q, commentIDs)
+}
+
+// ListReactorsByComment returns who reacted to a comment with which type, newest first
+// A nil reactionTypeID keeps every type
+func (r *CommentReactionRepository) ListReactorsByComment(ctx context.Context, commentID int64, reactionTypeID *int64, after *Cursor, limit int32) (_ []*Reactor, err error) {
+	defer observe(ctx, "CommentReactionRepository", "ListReactorsByComment")(&err)
+
+	const q = `
+        SELECT cr.comment_reaction_id, u.user_id, u.username, u.profile_picture, rt.reaction_type_id, rt.name, rt.image, cr.created_at
+        FROM comment_reactions cr
+        INNER JOIN users u ON u.user_id = cr.owner_id
+        INNER JOIN reaction_types rt ON rt.reaction_type_id = cr.reaction_type_id
+        WHERE cr.comment_id = $1
+          AND ($2::bigint IS NULL OR cr.reaction_type_id = $2)
+          AND ($3::timestamptz IS NULL OR (cr.created_at, cr.comment_reaction_id) < ($3, $4))
+        ORDER BY cr.created_at DESC, cr.comment_reaction_id DESC
+        LIMIT $5
+    `
+	afterTime, afterID := cursorArgs(after)
+	return queryReactors(ctx, r.db, q, commentID, reactionTypeID, afterTime, afterID, limit)
+}
+
+// GetTypesByOwnerAndComments returns the reaction type a user chose on each of the given comments
+// Comments the user did not react to are missing from the map
+func (r *CommentReactionRepository) GetTypesByOwnerAndComments(ctx context.Context, ownerID int64, commentIDs []int64) (_ map[int64]*entity.ReactionType, err error) {
+	defer observe(ctx, "CommentReactionRepository", "GetTypesByOwnerAndComments")(&err)
+
+	const q = `
+        SELECT rt.reaction_type_id, rt.name, rt.image, cr.comment_id
+        FROM comment_reactions cr
+        INNER JOIN reaction_types rt ON rt.reaction_type_id = cr.reaction_type_id
+        WHERE cr.owner_id = $1 AND cr.comment_id = ANY($2)
+    `
+	return queryReactionTypes(ctx, r.db, q, ownerID, commentIDs)
+}
+
 // Delete removes a reaction by its ID
-func (r *CommentReactionRepository) Delete(ctx context.Context, id int64) error {
+func (r *CommentReactionRepository) Delete(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "CommentReactionRepository", "Delete")(&err)
+
 	res, err := r.db.ExecContext(ctx, `DELETE FROM comment_reactions WHERE comment_reaction_id = $1`, id)
 	if err != nil {
 		return err
@@ -19,10 +19,12 @@ func NewCommentRepository(db *sql.DB) *CommentRepository {
 }
 
 // Create inserts a new comment into the database
-func (r *CommentRepository) Create(ctx context.Context, c *entity.Comment) (*entity.Comment, error) {
+func (r *CommentRepository) Create(ctx context.Context, c *entity.Comment) (_ *entity.Comment, err error) {
+	defer observe(ctx, "CommentRepository", "Create")(&err)
+
 	const q = `
-        INSERT INTO comments (post_id, owner_id, parent_comment_id, text, image, status, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        INSERT INTO comments (post_id, owner_id, parent_comment_id, text, image, status)
+        VALUES ($1, $2, $3, $4, $5, $6)
         RETURNING comment_id, created_at, updated_at
     `
 
@@ -35,7 +37,7 @@ func (r *CommentRepository) Create(ctx context.Context, c *entity.Comment) (*ent
 		image.String, image.Valid = *c.Image, true
 	}
 
-	err := r.db.QueryRowContext(ctx, q, c.PostID, c.OwnerID, parent, c.Text, image, c.Status).
+	err = r.db.QueryRowContext(ctx, q, c.PostID, c.OwnerID, parent, c.Text, image, c.Status).
 		Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
 	if err != nil {
 		return nil, err
@@ -44,7 +46,9 @@ func (r *CommentRepository) Create(ctx context.Context, c *entity.Comment) (*ent
 }
 
 // GetByID returns a comment by ID
-func (r *CommentRepository) GetByID(ctx context.Context, id int64) (*entity.Comment, error) {
+func (r *CommentRepository) GetByID(ctx context.Context, id int64) (_ *entity.Comment, err error) {
+	defer observe(ctx, "CommentRepository", "GetByID")(&err)
+
 	const q = `
         SELECT comment_id, post_id, owner_id, parent_comment_id, text, image, created_at, updated_at, status
         FROM comments
@@ -54,16 +58,20 @@ func (r *CommentRepository) GetByID(ctx context.Context, id int64) (*entity.Comm
 	return scanComment(row)
 }
 
-// ListByPost returns comments for a specific post
-func (r *CommentRepository) ListByPost(ctx context.Context, postID int64, limit, offset int32) ([]*entity.Comment, error) {
+// ListByPost returns comments for a specific post, oldest first
+func (r *CommentRepository) ListByPost(ctx context.Context, postID int64, after *Cursor, limit int32) (_ []*entity.Comment, err error) {
+	defer observe(ctx, "CommentRepository", "ListByPost")(&err)
+
 	const q = `
         SELECT comment_id, post_id, owner_id, parent_comment_id, text, image, created_at, updated_at, status
         FROM comments
         WHERE post_id = $1
-        ORDER BY comment_id ASC
-        LIMIT $2 OFFSET $3
+          AND ($2::timestamptz IS NULL OR (created_at, comment_id) > ($2, $3))
+        ORDER BY created_at ASC, comment_id ASC
+        LIMIT $4
     `
-	rows, err := r.db.QueryContext(ctx, q, postID, limit, offset)
+	afterTime, afterID := cursorArgs(after)
+	rows, err := r.db.QueryContext(ctx, q, postID, afterTime, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -83,16 +91,20 @@ func (r *CommentRepository) ListByPost(ctx context.Context, postID int64, limit,
 	return list, nil
 }
 
-// ListByParent returns replies to a specific comment
-func (r *CommentRepository) ListByParent(ctx context.Context, parentID int64, limit, offset int32) ([]*entity.Comment, error) {
+// ListByParent returns replies to a specific comment, oldest first
+func (r *CommentRepository) ListByParent(ctx context.Context, parentID int64, after *Cursor, limit int32) (_ []*entity.Comment, err error) {
+	defer observe(ctx, "CommentRepository", "ListByParent")(&err)
+
 	const q = `
         SELECT comment_id, post_id, owner_id, parent_comment_id, text, image, created_at, updated_at, status
         FROM comments
         WHERE parent_comment_id = $1
-        ORDER BY comment_id ASC
-        LIMIT $2 OFFSET $3
+          AND ($2::timestamptz IS NULL OR (created_at, comment_id) > ($2, $3))
+        ORDER BY created_at ASC, comment_id ASC
+        LIMIT $4
     `
-	rows, err := r.db.QueryContext(ctx, q, parentID, limit, offset)
+	afterTime, afterID := cursorArgs(after)
+	rows, err := r.db.QueryContext(ctx, q, parentID, afterTime, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -112,16 +124,20 @@ func (r *CommentRepository) ListByParent(ctx context.Context, parentID int64, li
 	return list, nil
 }
 
-// ListByOwner returns all comments by a user
-func (r *CommentRepository) ListByOwner(ctx context.Context, ownerID int64, limit, offset int32) ([]*entity.Comment, error) {
+// ListByOwner returns all comments by a user, newest first
+func (r *CommentRepository) ListByOwner(ctx context.Context, ownerID int64, after *Cursor, limit int32) (_ []*entity.Comment, err error) {
+	defer observe(ctx, "CommentRepository", "ListByOwner")(&err)
+
 	const q = `
         SELECT comment_id, post_id, owner_id, parent_comment_id, text, image, created_at, updated_at, status
         FROM comments
         WHERE owner_id = $1
-        ORDER BY comment_id DESC
-        LIMIT $2 OFFSET $3
+          AND ($2::timestamptz IS NULL OR (created_at, comment_id) < ($2, $3))
+        ORDER BY created_at DESC, comment_id DESC
+        LIMIT $4
     `
-	rows, err := r.db.QueryContext(ctx, q, ownerID, limit, offset)
+	afterTime, afterID := cursorArgs(after)
+	rows, err := r.db.QueryContext(ctx, q, ownerID, afterTime, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -141,17 +157,21 @@ func (r *CommentRepository) ListByOwner(ctx context.Context, ownerID int64, limi
 	return list, nil
 }
 
-// ListByOwnerAndCategory returns comments by a user in a specific category
-func (r *CommentRepository) ListByOwnerAndCategory(ctx context.Context, ownerID, categoryID int64, limit, offset int32) ([]*entity.Comment, error) {
+// ListByOwnerAndCategory returns comments by a user in a specific category, newest first
+func (r *CommentRepository) ListByOwnerAndCategory(ctx context.Context, ownerID, categoryID int64, after *Cursor, limit int32) (_ []*entity.Comment, err error) {
+	defer observe(ctx, "CommentRepository", "ListByOwnerAndCategory")(&err)
+
 	const q = `
-        SELECT DISTINCT c.comment_id, c.post_id, c.owner_id, c.parent_comment_id, c.text, c.image, c.created_at, c.updated_at, c.status
+        SELECT c.comment_id, c.post_id, c.owner_id, c.parent_comment_id, c.text, c.image, c.created_at, c.updated_at, c.status
         FROM comments c
         INNER JOIN posts p ON c.post_id = p.post_id
 				WHERE c.owner_id = $1 AND p.category_id = $2
-        ORDER BY c.comment_id DESC
-        LIMIT $3 OFFSET $4
+				  AND ($3::timestamptz IS NULL OR (c.created_at, c.comment_id) < ($3, $4))
+        ORDER BY c.created_at DESC, c.comment_id DESC
+        LIMIT $5
     `
-	rows, err := r.db.QueryContext(ctx, q, ownerID, categoryID, limit, offset)
+	afterTime, afterID := cursorArgs(after)
+	rows, err := r.db.QueryContext(ctx, q, ownerID, categoryID, afterTime, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -172,7 +192,9 @@ func (r *CommentRepository) ListByOwnerAndCategory(ctx context.Context, ownerID,
 }
 
 // Update modifies an existing comment
-func (r *CommentRepository) Update(ctx context.Context, c *entity.Comment) error {
+func (r *CommentRepository) Update(ctx context.Context, c *entity.Comment) (err error) {
+	defer observe(ctx, "CommentRepository", "Update")(&err)
+
 	const q = `
         UPDATE comments
 				SET text = $2, image = $3, status = TRUE, updated_at = NOW()
@@ -193,7 +215,9 @@ func (r *CommentRepository) Update(ctx context.Context, c *entity.Comment) error
 }
 
 // Delete removes a comment by its ID
-func (r *CommentRepository) Delete(ctx context.Context, id int64) error {
+func (r *CommentRepository) Delete(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "CommentRepository", "Delete")(&err)
+
 	res, err := r.db.ExecContext(ctx, `DELETE FROM comments WHERE comment_id = $1`, id)
 	if err != nil {
 		return err
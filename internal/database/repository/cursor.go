@@ -0,0 +1,18 @@
+package repository
+
+import "time"
+
+// Cursor marks a position in a list ordered by creation time and ID
+// List methods return the rows strictly after the cursor, a nil cursor starts from the beginning
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// cursorArgs expands a cursor into its query arguments, both NULL when there is no cursor
+func cursorArgs(after *Cursor) (any, any) {
+	if after == nil {
+		return nil, nil
+	}
+	return after.CreatedAt, after.ID
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ImageRepository looks up the images referenced by forum content
+type ImageRepository struct {
+	db *sql.DB
+}
+
+// NewImageRepository creates a new ImageRepository
+func NewImageRepository(db *sql.DB) *ImageRepository {
+	return &ImageRepository{db: db}
+}
+
+// ListReferences returns every image URL or key stored on users, posts, comments and reaction types
+func (r *ImageRepository) ListReferences(ctx context.Context) (_ []string, err error) {
+	defer observe(ctx, "ImageRepository", "ListReferences")(&err)
+
+	const q = `
+        SELECT profile_picture FROM users WHERE profile_picture IS NOT NULL
+        UNION
+        SELECT image FROM posts WHERE image IS NOT NULL
+        UNION
+        SELECT image FROM comments WHERE image IS NOT NULL
+        UNION
+        SELECT image FROM reaction_types WHERE image IS NOT NULL
+    `
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []string
+	for rows.Next() {
+		var ref string
+		if err := rows.Scan(&ref); err != nil {
+			return nil, err
+		}
+		list = append(list, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
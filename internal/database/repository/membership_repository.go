@@ -19,7 +19,9 @@ func NewMembershipRepository(db *sql.DB) *MembershipRepository {
 }
 
 // Create adds a new membership for a user in a category
-func (r *MembershipRepository) Create(ctx context.Context, m *entity.Membership) (*entity.Membership, error) {
+func (r *MembershipRepository) Create(ctx context.Context, m *entity.Membership) (_ *entity.Membership, err error) {
+	defer observe(ctx, "MembershipRepository", "Create")(&err)
+
 	const q = `
         INSERT INTO memberships (category_id, user_id)
         VALUES ($1, $2)
@@ -27,7 +29,7 @@ func (r *MembershipRepository) Create(ctx context.Context, m *entity.Membership)
         RETURNING membership_id, joined_date
     `
 	var joined sql.NullTime
-	err := r.db.QueryRowContext(ctx, q, m.CategoryID, m.UserID).Scan(&m.ID, &joined)
+	err = r.db.QueryRowContext(ctx, q, m.CategoryID, m.UserID).Scan(&m.ID, &joined)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return m, nil
@@ -41,7 +43,9 @@ func (r *MembershipRepository) Create(ctx context.Context, m *entity.Membership)
 }
 
 // Delete removes a membership by its ID
-func (r *MembershipRepository) Delete(ctx context.Context, id int64) error {
+func (r *MembershipRepository) Delete(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "MembershipRepository", "Delete")(&err)
+
 	res, err := r.db.ExecContext(ctx, `DELETE FROM memberships WHERE membership_id = $1`, id)
 	if err != nil {
 		return err
@@ -57,7 +61,9 @@ func (r *MembershipRepository) Delete(ctx context.Context, id int64) error {
 }
 
 // GetByUserAndCategory retrieves a membership by user ID and category ID
-func (r *MembershipRepository) GetByUserAndCategory(ctx context.Context, userID, categoryID int64) (*entity.Membership, error) {
+func (r *MembershipRepository) GetByUserAndCategory(ctx context.Context, userID, categoryID int64) (_ *entity.Membership, err error) {
+	defer observe(ctx, "MembershipRepository", "GetByUserAndCategory")(&err)
+
 	const q = `
 				SELECT membership_id, category_id, user_id, joined_date
 				FROM memberships
@@ -68,7 +74,9 @@ func (r *MembershipRepository) GetByUserAndCategory(ctx context.Context, userID,
 }
 
 // DeleteByUserAndCategory removes membership by user and category IDs
-func (r *MembershipRepository) DeleteByUserAndCategory(ctx context.Context, userID, categoryID int64) error {
+func (r *MembershipRepository) DeleteByUserAndCategory(ctx context.Context, userID, categoryID int64) (err error) {
+	defer observe(ctx, "MembershipRepository", "DeleteByUserAndCategory")(&err)
+
 	res, err := r.db.ExecContext(ctx, `DELETE FROM memberships WHERE user_id = $1 AND category_id = $2`, userID, categoryID)
 	if err != nil {
 		return err
@@ -84,7 +92,9 @@ func (r *MembershipRepository) DeleteByUserAndCategory(ctx context.Context, user
 }
 
 // GetByUserID returns all memberships for a user
-func (r *MembershipRepository) GetByUserID(ctx context.Context, userID int64) ([]*entity.Membership, error) {
+func (r *MembershipRepository) GetByUserID(ctx context.Context, userID int64) (_ []*entity.Membership, err error) {
+	defer observe(ctx, "MembershipRepository", "GetByUserID")(&err)
+
 	const q = `
         SELECT membership_id, category_id, user_id, joined_date
         FROM memberships
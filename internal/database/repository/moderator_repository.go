@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"my-chi-app/internal/domain/entity"
+)
+
+// ModeratorRepository manages per-category moderators
+type ModeratorRepository struct {
+	db *sql.DB
+}
+
+// NewModeratorRepository creates a new ModeratorRepository
+func NewModeratorRepository(db *sql.DB) *ModeratorRepository {
+	return &ModeratorRepository{db: db}
+}
+
+// Create grants a user moderation rights in a category
+func (r *ModeratorRepository) Create(ctx context.Context, m *entity.Moderator) (_ *entity.Moderator, err error) {
+	defer observe(ctx, "ModeratorRepository", "Create")(&err)
+
+	const q = `
+        INSERT INTO category_moderators (category_id, user_id)
+        VALUES ($1, $2)
+        ON CONFLICT (category_id, user_id) DO UPDATE SET category_id = EXCLUDED.category_id
+        RETURNING moderator_id, created_at
+    `
+	err = r.db.QueryRowContext(ctx, q, m.CategoryID, m.UserID).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DeleteByUserAndCategory revokes a user's moderation rights in a category
+func (r *ModeratorRepository) DeleteByUserAndCategory(ctx context.Context, userID, categoryID int64) (err error) {
+	defer observe(ctx, "ModeratorRepository", "DeleteByUserAndCategory")(&err)
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM category_moderators WHERE user_id = $1 AND category_id = $2`, userID, categoryID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// IsModerator reports whether a user moderates a category
+func (r *ModeratorRepository) IsModerator(ctx context.Context, userID, categoryID int64) (_ bool, err error) {
+	defer observe(ctx, "ModeratorRepository", "IsModerator")(&err)
+
+	const q = `SELECT EXISTS (SELECT 1 FROM category_moderators WHERE user_id = $1 AND category_id = $2)`
+	var exists bool
+	err = r.db.QueryRowContext(ctx, q, userID, categoryID).Scan(&exists)
+	return exists, err
+}
+
+// ListByCategory returns all moderators of a category
+func (r *ModeratorRepository) ListByCategory(ctx context.Context, categoryID int64) (_ []*entity.Moderator, err error) {
+	defer observe(ctx, "ModeratorRepository", "ListByCategory")(&err)
+
+	const q = `
+        SELECT moderator_id, category_id, user_id, created_at
+        FROM category_moderators
+        WHERE category_id = $1
+        ORDER BY created_at ASC
+    `
+	rows, err := r.db.QueryContext(ctx, q, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*entity.Moderator
+	for rows.Next() {
+		m, err := scanModerator(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// moderatorRowScanner defines the interface for scanning moderator rows
+type moderatorRowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanModerator scans a moderator from the given row scanner
+func scanModerator(rs moderatorRowScanner) (*entity.Moderator, error) {
+	var m entity.Moderator
+	if err := rs.Scan(&m.ID, &m.CategoryID, &m.UserID, &m.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &m, nil
+}
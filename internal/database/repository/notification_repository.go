@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"my-chi-app/internal/domain/entity"
 )
@@ -19,13 +20,15 @@ func NewNotificationRepository(db *sql.DB) *NotificationRepository {
 }
 
 // Create inserts a new notification into the database
-func (r *NotificationRepository) Create(ctx context.Context, n *entity.Notification) (*entity.Notification, error) {
+func (r *NotificationRepository) Create(ctx context.Context, n *entity.Notification) (_ *entity.Notification, err error) {
+	defer observe(ctx, "NotificationRepository", "Create")(&err)
+
 	const q = `
         INSERT INTO notifications (owner_id, actor_id, component_type, component_id, notification_type, status)
         VALUES ($1, $2, $3, $4, $5, $6)
         RETURNING notification_id, created_at, status
     `
-	err := r.db.QueryRowContext(ctx, q, n.OwnerID, n.ActorID, n.ComponentType, n.ComponentID, n.NotificationType, n.Status).
+	err = r.db.QueryRowContext(ctx, q, n.OwnerID, n.ActorID, n.ComponentType, n.ComponentID, n.NotificationType, n.Status).
 		Scan(&n.ID, &n.CreatedAt, &n.Status)
 	if err != nil {
 		return nil, err
@@ -34,7 +37,9 @@ func (r *NotificationRepository) Create(ctx context.Context, n *entity.Notificat
 }
 
 // GetByID retrieves a notification by its ID
-func (r *NotificationRepository) GetByID(ctx context.Context, id int64) (*entity.Notification, error) {
+func (r *NotificationRepository) GetByID(ctx context.Context, id int64) (_ *entity.Notification, err error) {
+	defer observe(ctx, "NotificationRepository", "GetByID")(&err)
+
 	const q = `
         SELECT notification_id, owner_id, actor_id, component_type, component_id, notification_type, status, created_at
         FROM notifications
@@ -44,16 +49,20 @@ func (r *NotificationRepository) GetByID(ctx context.Context, id int64) (*entity
 	return scanNotification(row)
 }
 
-// ListByOwner returns notifications for a specific user
-func (r *NotificationRepository) ListByOwner(ctx context.Context, ownerID int64, limit, offset int32) ([]*entity.Notification, error) {
+// ListByOwner returns notifications for a specific user, newest first
+func (r *NotificationRepository) ListByOwner(ctx context.Context, ownerID int64, after *Cursor, limit int32) (_ []*entity.Notification, err error) {
+	defer observe(ctx, "NotificationRepository", "ListByOwner")(&err)
+
 	const q = `
         SELECT notification_id, owner_id, actor_id, component_type, component_id, notification_type, status, created_at
         FROM notifications
         WHERE owner_id = $1
-        ORDER BY notification_id DESC
-        LIMIT $2 OFFSET $3
+          AND ($2::timestamptz IS NULL OR (created_at, notification_id) < ($2, $3))
+        ORDER BY created_at DESC, notification_id DESC
+        LIMIT $4
     `
-	rows, err := r.db.QueryContext(ctx, q, ownerID, limit, offset)
+	afterTime, afterID := cursorArgs(after)
+	rows, err := r.db.QueryContext(ctx, q, ownerID, afterTime, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -73,16 +82,20 @@ func (r *NotificationRepository) ListByOwner(ctx context.Context, ownerID int64,
 	return list, nil
 }
 
-// ListByOwnerAndStatus returns notifications for a user filtered by read or unread status
-func (r *NotificationRepository) ListByOwnerAndStatus(ctx context.Context, ownerID int64, status bool, limit, offset int32) ([]*entity.Notification, error) {
+// ListByOwnerAndStatus returns notifications for a user filtered by read or unread status, newest first
+func (r *NotificationRepository) ListByOwnerAndStatus(ctx context.Context, ownerID int64, status bool, after *Cursor, limit int32) (_ []*entity.Notification, err error) {
+	defer observe(ctx, "NotificationRepository", "ListByOwnerAndStatus")(&err)
+
 	const q = `
 				SELECT notification_id, owner_id, actor_id, component_type, component_id, notification_type, status, created_at
 				FROM notifications
 				WHERE owner_id = $1 AND status = $2
-				ORDER BY notification_id DESC
-				LIMIT $3 OFFSET $4
+				  AND ($3::timestamptz IS NULL OR (created_at, notification_id) < ($3, $4))
+				ORDER BY created_at DESC, notification_id DESC
+				LIMIT $5
 	`
-	rows, err := r.db.QueryContext(ctx, q, ownerID, status, limit, offset)
+	afterTime, afterID := cursorArgs(after)
+	rows, err := r.db.QueryContext(ctx, q, ownerID, status, afterTime, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -102,8 +115,66 @@ func (r *NotificationRepository) ListByOwnerAndStatus(ctx context.Context, owner
 	return list, nil
 }
 
+// ListByOwnerAfter returns notifications for a user with an ID above the given one, oldest first
+// IDs come from a sequence and may commit out of order, a lower ID committed later is not returned
+func (r *NotificationRepository) ListByOwnerAfter(ctx context.Context, ownerID, afterID int64, limit int32) (_ []*entity.Notification, err error) {
+	defer observe(ctx, "NotificationRepository", "ListByOwnerAfter")(&err)
+
+	const q = `
+        SELECT notification_id, owner_id, actor_id, component_type, component_id, notification_type, status, created_at
+        FROM notifications
+        WHERE owner_id = $1 AND notification_id > $2
+        ORDER BY notification_id ASC
+        LIMIT $3
+    `
+	rows, err := r.db.QueryContext(ctx, q, ownerID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*entity.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// CreateCollapsed inserts a reaction notification unless the actor already triggered one on the component
+// It reports whether a notification was created, the unique idx_notifications_collapse index makes it safe under concurrency
+func (r *NotificationRepository) CreateCollapsed(ctx context.Context, n *entity.Notification) (_ bool, err error) {
+	defer observe(ctx, "NotificationRepository", "CreateCollapsed")(&err)
+
+	const q = `
+        INSERT INTO notifications (owner_id, actor_id, component_type, component_id, notification_type, status)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (owner_id, actor_id, component_type, component_id, notification_type)
+            WHERE notification_type = 'reaction'
+            DO NOTHING
+        RETURNING notification_id, created_at, status
+    `
+	err = r.db.QueryRowContext(ctx, q, n.OwnerID, n.ActorID, n.ComponentType, n.ComponentID, n.NotificationType, n.Status).
+		Scan(&n.ID, &n.CreatedAt, &n.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // MarkRead marks a notification as read
-func (r *NotificationRepository) MarkRead(ctx context.Context, id int64) error {
+func (r *NotificationRepository) MarkRead(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "NotificationRepository", "MarkRead")(&err)
+
 	res, err := r.db.ExecContext(ctx, `UPDATE notifications SET status = TRUE WHERE notification_id = $1`, id)
 	if err != nil {
 		return err
@@ -119,7 +190,9 @@ func (r *NotificationRepository) MarkRead(ctx context.Context, id int64) error {
 }
 
 // MarkUnread marks a notification as unread
-func (r *NotificationRepository) MarkUnread(ctx context.Context, id int64) error {
+func (r *NotificationRepository) MarkUnread(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "NotificationRepository", "MarkUnread")(&err)
+
 	res, err := r.db.ExecContext(ctx, `UPDATE notifications SET status = FALSE WHERE notification_id = $1`, id)
 	if err != nil {
 		return err
@@ -134,6 +207,17 @@ func (r *NotificationRepository) MarkUnread(ctx context.Context, id int64) error
 	return nil
 }
 
+// DeleteReadBefore deletes read notifications created before the cutoff time
+func (r *NotificationRepository) DeleteReadBefore(ctx context.Context, cutoff time.Time) (_ int64, err error) {
+	defer observe(ctx, "NotificationRepository", "DeleteReadBefore")(&err)
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM notifications WHERE status = TRUE AND created_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // notificationRowScanner defines the interface for scanning notification rows
 type notificationRowScanner interface {
 	Scan(dest ...any) error
@@ -0,0 +1,43 @@
+package repository
+
+import "context"
+
+// Observer is notified when a repository method starts and returns the function to call with its error when it returns
+// It is used to record query durations and trace database calls
+type Observer func(ctx context.Context, repository, method string) func(err error)
+
+// observer is installed once at startup by SetObserver
+var observer Observer
+
+// SetObserver installs the observer of the repository methods
+// It must be called before the repositories are used, nil removes it
+func SetObserver(o Observer) {
+	observer = o
+}
+
+// observe starts observing a repository method, the returned function ends the observation
+// It takes a pointer to the named error result so a deferred call sees the error the method returns
+func observe(ctx context.Context, repository, method string) func(err *error) {
+	if observer == nil {
+		return func(*error) {}
+	}
+	end := observer(ctx, repository, method)
+	return func(err *error) {
+		end(*err)
+	}
+}
+
+// Observers combines several observers into one, ending the observations in reverse order
+func Observers(observers ...Observer) Observer {
+	return func(ctx context.Context, repository, method string) func(err error) {
+		ends := make([]func(error), len(observers))
+		for i, o := range observers {
+			ends[i] = o(ctx, repository, method)
+		}
+		return func(err error) {
+			for i := len(ends) - 1; i >= 0; i-- {
+				ends[i](err)
+			}
+		}
+	}
+}
@@ -4,10 +4,74 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"my-chi-app/internal/domain/entity"
+
+	"github.com/lib/pq"
+)
+
+// PostSort selects the order of a post listing
+type PostSort string
+
+// Supported post orders
+const (
+	// PostSortNew orders by creation time
+	PostSortNew PostSort = "new"
+	// PostSortTop orders by number of reactions
+	PostSortTop PostSort = "top"
+	// PostSortHot orders by reactions decayed by age
+	PostSortHot PostSort = "hot"
+	// PostSortActive orders by the latest comment, or creation time without comments
+	PostSortActive PostSort = "active"
 )
 
+// PostCursor marks a position in a sorted post listing
+// Time holds the sort key of the new and active orders, Score the one of top and hot
+type PostCursor struct {
+	Time  time.Time
+	Score float64
+	ID    int64
+}
+
+// PostListOptions controls the order, time window and page of a post listing
+type PostListOptions struct {
+	Sort PostSort
+	// Since keeps only posts created at or after it, nil keeps all
+	Since *time.Time
+	// After continues a previous page, nil starts from the top
+	After *PostCursor
+	Limit int32
+}
+
+// postSortKey describes the column a post order sorts on
+type postSortKey struct {
+	column string
+	isTime bool
+	isInt  bool
+}
+
+// value returns the cursor position as the query argument matching the column type
+func (k postSortKey) value(c *PostCursor) any {
+	switch {
+	case k.isTime:
+		return c.Time
+	case k.isInt:
+		return int64(c.Score)
+	default:
+		return c.Score
+	}
+}
+
+// postSortKeys maps each order to its sort key, scores come from the trigger maintained post_stats table
+var postSortKeys = map[PostSort]postSortKey{
+	PostSortNew:    {column: "p.created_at", isTime: true},
+	PostSortTop:    {column: "s.reaction_count", isInt: true},
+	PostSortHot:    {column: "s.hot_score"},
+	PostSortActive: {column: "s.last_activity_at", isTime: true},
+}
+
 // PostRepository manages posts
 type PostRepository struct {
 	db *sql.DB
@@ -19,7 +83,9 @@ func NewPostRepository(db *sql.DB) *PostRepository {
 }
 
 // Create inserts a new post into the database
-func (r *PostRepository) Create(ctx context.Context, p *entity.Post) (*entity.Post, error) {
+func (r *PostRepository) Create(ctx context.Context, p *entity.Post) (_ *entity.Post, err error) {
+	defer observe(ctx, "PostRepository", "Create")(&err)
+
 	const q = `
 				INSERT INTO posts (owner_id, category_id, headline, text, image, status)
 				VALUES ($1, $2, $3, $4, $5, $6)
@@ -35,7 +101,7 @@ func (r *PostRepository) Create(ctx context.Context, p *entity.Post) (*entity.Po
 		image.String, image.Valid = *p.Image, true
 	}
 
-	err := r.db.QueryRowContext(ctx, q, p.OwnerID, p.CategoryID, p.Headline, text, image, p.Status).
+	err = r.db.QueryRowContext(ctx, q, p.OwnerID, p.CategoryID, p.Headline, text, image, p.Status).
 		Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
 		return nil, err
@@ -43,7 +109,9 @@ func (r *PostRepository) Create(ctx context.Context, p *entity.Post) (*entity.Po
 	return p, nil
 }
 
-func (r *PostRepository) GetByID(ctx context.Context, id int64) (*entity.Post, error) {
+func (r *PostRepository) GetByID(ctx context.Context, id int64) (_ *entity.Post, err error) {
+	defer observe(ctx, "PostRepository", "GetByID")(&err)
+
 	const q = `
 				SELECT post_id, owner_id, category_id, headline, text, image, created_at, updated_at, status
         FROM posts
@@ -53,36 +121,10 @@ func (r *PostRepository) GetByID(ctx context.Context, id int64) (*entity.Post, e
 	return scanPost(row)
 }
 
-// List returns all posts with pagination
-func (r *PostRepository) List(ctx context.Context, limit, offset int32) ([]*entity.Post, error) {
-	const q = `
-				SELECT post_id, owner_id, category_id, headline, text, image, created_at, updated_at, status
-        FROM posts
-        ORDER BY post_id DESC
-        LIMIT $1 OFFSET $2
-    `
-	rows, err := r.db.QueryContext(ctx, q, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var list []*entity.Post
-	for rows.Next() {
-		p, err := scanPost(rows)
-		if err != nil {
-			return nil, err
-		}
-		list = append(list, p)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return list, nil
-}
-
 // Delete removes a post by ID
-func (r *PostRepository) Delete(ctx context.Context, id int64) error {
+func (r *PostRepository) Delete(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "PostRepository", "Delete")(&err)
+
 	res, err := r.db.ExecContext(ctx, `DELETE FROM posts WHERE post_id = $1`, id)
 	if err != nil {
 		return err
@@ -97,95 +139,104 @@ func (r *PostRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+// List returns posts from every category
+func (r *PostRepository) List(ctx context.Context, opts PostListOptions) (_ []*entity.Post, _ *PostCursor, err error) {
+	defer observe(ctx, "PostRepository", "List")(&err)
+
+	return r.listPosts(ctx, "TRUE", nil, opts)
+}
+
 // GetByOwner returns posts created by a user
-func (r *PostRepository) GetByOwner(ctx context.Context, ownerID int64, limit, offset int32) ([]*entity.Post, error) {
-	const q = `
-				SELECT post_id, owner_id, category_id, headline, text, image, created_at, updated_at, status
-        FROM posts
-        WHERE owner_id = $1
-        ORDER BY post_id DESC
-        LIMIT $2 OFFSET $3
-    `
-	rows, err := r.db.QueryContext(ctx, q, ownerID, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+func (r *PostRepository) GetByOwner(ctx context.Context, ownerID int64, opts PostListOptions) (_ []*entity.Post, _ *PostCursor, err error) {
+	defer observe(ctx, "PostRepository", "GetByOwner")(&err)
 
-	var list []*entity.Post
-	for rows.Next() {
-		p, err := scanPost(rows)
-		if err != nil {
-			return nil, err
-		}
-		list = append(list, p)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return list, nil
+	return r.listPosts(ctx, "p.owner_id = $1", []any{ownerID}, opts)
 }
 
 // GetByCategory returns posts in a category
-func (r *PostRepository) GetByCategory(ctx context.Context, categoryID int64, limit, offset int32) ([]*entity.Post, error) {
-	const q = `
-				SELECT p.post_id, p.owner_id, p.category_id, p.headline, p.text, p.image, p.created_at, p.updated_at, p.status
-				FROM posts p
-				WHERE p.category_id = $1
-				ORDER BY p.post_id DESC
-				LIMIT $2 OFFSET $3
-    `
-	rows, err := r.db.QueryContext(ctx, q, categoryID, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+func (r *PostRepository) GetByCategory(ctx context.Context, categoryID int64, opts PostListOptions) (_ []*entity.Post, _ *PostCursor, err error) {
+	defer observe(ctx, "PostRepository", "GetByCategory")(&err)
 
-	var list []*entity.Post
-	for rows.Next() {
-		p, err := scanPost(rows)
-		if err != nil {
-			return nil, err
-		}
-		list = append(list, p)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return list, nil
+	return r.listPosts(ctx, "p.category_id = $1", []any{categoryID}, opts)
+}
+
+// GetByCategories returns posts from any of the given categories
+func (r *PostRepository) GetByCategories(ctx context.Context, categoryIDs []int64, opts PostListOptions) (_ []*entity.Post, _ *PostCursor, err error) {
+	defer observe(ctx, "PostRepository", "GetByCategories")(&err)
+
+	return r.listPosts(ctx, "p.category_id = ANY($1)", []any{pq.Array(categoryIDs)}, opts)
 }
 
 // GetByOwnerAndCategory returns user's posts in a specific category
-func (r *PostRepository) GetByOwnerAndCategory(ctx context.Context, ownerID, categoryID int64, limit, offset int32) ([]*entity.Post, error) {
-	const q = `
-				SELECT p.post_id, p.owner_id, p.category_id, p.headline, p.text, p.image, p.created_at, p.updated_at, p.status
+func (r *PostRepository) GetByOwnerAndCategory(ctx context.Context, ownerID, categoryID int64, opts PostListOptions) (_ []*entity.Post, _ *PostCursor, err error) {
+	defer observe(ctx, "PostRepository", "GetByOwnerAndCategory")(&err)
+
+	return r.listPosts(ctx, "p.owner_id = $1 AND p.category_id = $2", []any{ownerID, categoryID}, opts)
+}
+
+// listPosts runs a sorted, keyset paginated post query restricted by the filter
+// The filter is a trusted SQL condition whose placeholders match args
+func (r *PostRepository) listPosts(ctx context.Context, filter string, args []any, opts PostListOptions) ([]*entity.Post, *PostCursor, error) {
+	key, ok := postSortKeys[opts.Sort]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown post sort %q", opts.Sort)
+	}
+
+	q := `
+				SELECT p.post_id, p.owner_id, p.category_id, p.headline, p.text, p.image, p.created_at, p.updated_at, p.status, ` + key.column + `
 				FROM posts p
-				WHERE p.owner_id = $1 AND p.category_id = $2
-				ORDER BY p.post_id DESC
-				LIMIT $3 OFFSET $4
-    `
-	rows, err := r.db.QueryContext(ctx, q, ownerID, categoryID, limit, offset)
+				INNER JOIN post_stats s ON s.post_id = p.post_id
+				WHERE ` + filter
+	if opts.Since != nil {
+		args = append(args, *opts.Since)
+		q += fmt.Sprintf(" AND p.created_at >= $%d", len(args))
+	}
+	if opts.After != nil {
+		args = append(args, key.value(opts.After), opts.After.ID)
+		q += fmt.Sprintf(" AND (%s, p.post_id) < ($%d, $%d)", key.column, len(args)-1, len(args))
+	}
+	// One extra row tells whether another page follows
+	args = append(args, opts.Limit+1)
+	q += fmt.Sprintf(" ORDER BY %s DESC, p.post_id DESC LIMIT $%d", key.column, len(args))
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var list []*entity.Post
+	var (
+		list    []*entity.Post
+		cursors []PostCursor
+	)
 	for rows.Next() {
-		p, err := scanPost(rows)
+		var c PostCursor
+		sortValue := any(&c.Score)
+		if key.isTime {
+			sortValue = &c.Time
+		}
+		p, err := scanPost(extraColumnsScanner{rs: rows, extra: []any{sortValue}})
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		c.ID = p.ID
 		list = append(list, p)
+		cursors = append(cursors, c)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if int32(len(list)) <= opts.Limit {
+		return list, nil, nil
 	}
-	return list, nil
+	return list[:opts.Limit], &cursors[opts.Limit-1], nil
 }
 
 // Update modifies an existing post
-func (r *PostRepository) Update(ctx context.Context, p *entity.Post) error {
+func (r *PostRepository) Update(ctx context.Context, p *entity.Post) (err error) {
+	defer observe(ctx, "PostRepository", "Update")(&err)
+
 	const q = `
         UPDATE posts
 				SET headline = $2, text = $3, image = $4, status = TRUE, updated_at = NOW()
@@ -205,6 +256,37 @@ func (r *PostRepository) Update(ctx context.Context, p *entity.Post) error {
 	return nil
 }
 
+// RefreshStats recomputes the ranking counters of every post from the reactions and comments
+// The triggers keep post_stats current, this repairs drift and rows missed by the triggers
+// Returns the number of posts whose counters changed
+func (r *PostRepository) RefreshStats(ctx context.Context) (_ int64, err error) {
+	defer observe(ctx, "PostRepository", "RefreshStats")(&err)
+
+	const q = `
+        INSERT INTO post_stats (post_id, reaction_count, comment_count, hot_score, last_activity_at)
+        SELECT p.post_id,
+               COALESCE(rc.total, 0),
+               COALESCE(cc.total, 0),
+               post_hot_score(COALESCE(rc.total, 0), p.created_at),
+               greatest(p.created_at, cc.latest)
+        FROM posts p
+        LEFT JOIN (SELECT post_id, COUNT(*) AS total FROM reactions GROUP BY post_id) rc ON rc.post_id = p.post_id
+        LEFT JOIN (SELECT post_id, COUNT(*) AS total, MAX(created_at) AS latest FROM comments GROUP BY post_id) cc ON cc.post_id = p.post_id
+        ON CONFLICT (post_id) DO UPDATE
+        SET reaction_count = EXCLUDED.reaction_count,
+            comment_count = EXCLUDED.comment_count,
+            hot_score = EXCLUDED.hot_score,
+            last_activity_at = EXCLUDED.last_activity_at
+        WHERE (post_stats.reaction_count, post_stats.comment_count, post_stats.hot_score, post_stats.last_activity_at)
+            IS DISTINCT FROM (EXCLUDED.reaction_count, EXCLUDED.comment_count, EXCLUDED.hot_score, EXCLUDED.last_activity_at)
+    `
+	res, err := r.db.ExecContext(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // postRowScanner defines the interface for scanning post rows
 type postRowScanner interface {
 	Scan(dest ...any) error
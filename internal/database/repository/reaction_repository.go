@@ -4,10 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"my-chi-app/internal/domain/entity"
 )
 
+// ReactionTypeCount is the number of reactions of one type on a post or comment
+type ReactionTypeCount struct {
+	Type  *entity.ReactionType
+	Count int64
+}
+
+// Reactor is a user who reacted to a post or comment, with the type they chose
+type Reactor struct {
+	ReactionID     int64
+	UserID         int64
+	Username       string
+	ProfilePicture *string
+	Type           *entity.ReactionType
+	CreatedAt      time.Time
+}
+
 // ReactionRepository manages reactions on posts
 type ReactionRepository struct {
 	db *sql.DB
@@ -19,15 +36,19 @@ func NewReactionRepository(db *sql.DB) *ReactionRepository {
 }
 
 // Upsert sets a reaction for a post by owner, replacing existing one
-func (r *ReactionRepository) Upsert(ctx context.Context, rec *entity.Reaction) (*entity.Reaction, error) {
+// Changing the reaction type resets created_at, so reactor lists show when the current reaction was made
+func (r *ReactionRepository) Upsert(ctx context.Context, rec *entity.Reaction) (_ *entity.Reaction, err error) {
+	defer observe(ctx, "ReactionRepository", "Upsert")(&err)
+
 	const q = `
         INSERT INTO reactions (post_id, owner_id, reaction_type_id)
         VALUES ($1, $2, $3)
         ON CONFLICT (post_id, owner_id)
-        DO UPDATE SET reaction_type_id = EXCLUDED.reaction_type_id
+        DO UPDATE SET reaction_type_id = EXCLUDED.reaction_type_id,
+                      created_at = CASE WHEN reactions.reaction_type_id = EXCLUDED.reaction_type_id THEN reactions.created_at ELSE NOW() END
         RETURNING reaction_id
     `
-	err := r.db.QueryRowContext(ctx, q, rec.PostID, rec.OwnerID, rec.ReactionTypeID).Scan(&rec.ID)
+	err = r.db.QueryRowContext(ctx, q, rec.PostID, rec.OwnerID, rec.ReactionTypeID).Scan(&rec.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -35,7 +56,9 @@ func (r *ReactionRepository) Upsert(ctx context.Context, rec *entity.Reaction) (
 }
 
 // GetByOwnerAndPost retrieves a reaction by owner and post IDs
-func (r *ReactionRepository) GetByOwnerAndPost(ctx context.Context, ownerID, postID int64) (*entity.Reaction, error) {
+func (r *ReactionRepository) GetByOwnerAndPost(ctx context.Context, ownerID, postID int64) (_ *entity.Reaction, err error) {
+	defer observe(ctx, "ReactionRepository", "GetByOwnerAndPost")(&err)
+
 	const q = `
         SELECT reaction_id, post_id, owner_id, reaction_type_id
         FROM reactions
@@ -46,7 +69,9 @@ func (r *ReactionRepository) GetByOwnerAndPost(ctx context.Context, ownerID, pos
 }
 
 // Delete removes a reaction by ID
-func (r *ReactionRepository) Delete(ctx context.Context, id int64) error {
+func (r *ReactionRepository) Delete(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "ReactionRepository", "Delete")(&err)
+
 	res, err := r.db.ExecContext(ctx, `DELETE FROM reactions WHERE reaction_id = $1`, id)
 	if err != nil {
 		return err
@@ -61,14 +86,84 @@ func (r *ReactionRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+// DeleteByOwnerAndPost removes the reaction of a user on a post
+func (r *ReactionRepository) DeleteByOwnerAndPost(ctx context.Context, ownerID, postID int64) (err error) {
+	defer observe(ctx, "ReactionRepository", "DeleteByOwnerAndPost")(&err)
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM reactions WHERE owner_id = $1 AND post_id = $2`, ownerID, postID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // CountByPost counts total reactions on a post
-func (r *ReactionRepository) CountByPost(ctx context.Context, postID int64) (int64, error) {
+func (r *ReactionRepository) CountByPost(ctx context.Context, postID int64) (_ int64, err error) {
+	defer observe(ctx, "ReactionRepository", "CountByPost")(&err)
+
 	var count int64
 	const q = `SELECT COUNT(*) FROM reactions WHERE post_id = $1`
-	err := r.db.QueryRowContext(ctx, q, postID).Scan(&count)
+	err = r.db.QueryRowContext(ctx, q, postID).Scan(&count)
 	return count, err
 }
 
+// CountTypesByPosts counts the reactions of several posts per reaction type in one query
+// Counts are ordered from the most used type, posts without reactions are missing from the map
+func (r *ReactionRepository) CountTypesByPosts(ctx context.Context, postIDs []int64) (_ map[int64][]*ReactionTypeCount, err error) {
+	defer observe(ctx, "ReactionRepository", "CountTypesByPosts")(&err)
+
+	const q = `
+        SELECT rt.reaction_type_id, rt.name, rt.image, r.post_id, COUNT(*)
+        FROM reactions r
+        INNER JOIN reaction_types rt ON rt.reaction_type_id = r.reaction_type_id
+        WHERE r.post_id = ANY($1)
+        GROUP BY r.post_id, rt.reaction_type_id, rt.name, rt.image
+        ORDER BY r.post_id, COUNT(*) DESC, rt.reaction_type_id
+    `
+	return queryReactionCounts(ctx, r.db, q, postIDs)
+}
+
+// ListReactorsByPost returns who reacted to a post with which type, newest first
+// A nil reactionTypeID keeps every type
+func (r *ReactionRepository) ListReactorsByPost(ctx context.Context, postID int64, reactionTypeID *int64, after *Cursor, limit int32) (_ []*Reactor, err error) {
+	defer observe(ctx, "ReactionRepository", "ListReactorsByPost")(&err)
+
+	const q = `
+        SELECT r.reaction_id, u.user_id, u.username, u.profile_picture, rt.reaction_type_id, rt.name, rt.image, r.created_at
+        FROM reactions r
+        INNER JOIN users u ON u.user_id = r.owner_id
+        INNER JOIN reaction_types rt ON rt.reaction_type_id = r.reaction_type_id
+        WHERE r.post_id = $1
+          AND ($2::bigint IS NULL OR r.reaction_type_id = $2)
+          AND ($3::timestamptz IS NULL OR (r.created_at, r.reaction_id) < ($3, $4))
+        ORDER BY r.created_at DESC, r.reaction_id DESC
+        LIMIT $5
+    `
+	afterTime, afterID := cursorArgs(after)
+	return queryReactors(ctx, r.db, q, postID, reactionTypeID, afterTime, afterID, limit)
+}
+
+// GetTypesByOwnerAndPosts returns the reaction type a user chose on each of the given posts
+// Posts the user did not react to are missing from the map
+func (r *ReactionRepository) GetTypesByOwnerAndPosts(ctx context.Context, ownerID int64, postIDs []int64) (_ map[int64]*entity.ReactionType, err error) {
+	defer observe(ctx, "ReactionRepository", "GetTypesByOwnerAndPosts")(&err)
+
+	const q = `
+        SELECT rt.reaction_type_id, rt.name, rt.image, r.post_id
+        FROM reactions r
+        INNER JOIN reaction_types rt ON rt.reaction_type_id = r.reaction_type_id
+        WHERE r.owner_id = $1 AND r.post_id = ANY($2)
+    `
+	return queryReactionTypes(ctx, r.db, q, ownerID, postIDs)
+}
+
 // reactionRowScanner defines the interface for scanning reaction rows
 type reactionRowScanner interface {
 	Scan(dest ...any) error
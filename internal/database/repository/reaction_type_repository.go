@@ -19,39 +19,46 @@ func NewReactionTypeRepository(db *sql.DB) *ReactionTypeRepository {
 }
 
 // Create inserts a new reaction type into the database
-func (r *ReactionTypeRepository) Create(ctx context.Context, rt *entity.ReactionType) (*entity.ReactionType, error) {
+func (r *ReactionTypeRepository) Create(ctx context.Context, rt *entity.ReactionType) (_ *entity.ReactionType, err error) {
+	defer observe(ctx, "ReactionTypeRepository", "Create")(&err)
+
 	const q = `
         INSERT INTO reaction_types (name, image)
         VALUES ($1, $2)
         RETURNING reaction_type_id
     `
 
-	var image sql.NullString
-	if rt.Image != nil && *rt.Image != "" {
-		image.String, image.Valid = *rt.Image, true
-	}
-
-	err := r.db.QueryRowContext(ctx, q, rt.Name, image).Scan(&rt.ID)
+	err = r.db.QueryRowContext(ctx, q, rt.Name, nullableImage(rt.Image)).Scan(&rt.ID)
 	if err != nil {
 		return nil, err
 	}
 	return rt, nil
 }
 
-// GetByID returns a reaction type by ID
-func (r *ReactionTypeRepository) GetByID(ctx context.Context, id int64) (*entity.ReactionType, error) {
+// GetByID returns a reaction type by ID, retired or not
+func (r *ReactionTypeRepository) GetByID(ctx context.Context, id int64) (_ *entity.ReactionType, err error) {
+	defer observe(ctx, "ReactionTypeRepository", "GetByID")(&err)
+
 	const q = `
-        SELECT reaction_type_id, name, image
+        SELECT reaction_type_id, name, image, retired_at
         FROM reaction_types
         WHERE reaction_type_id = $1
     `
 	row := r.db.QueryRowContext(ctx, q, id)
-	return scanReactionType(row)
+	return scanManagedReactionType(row)
 }
 
-// List returns all reaction types
-func (r *ReactionTypeRepository) List(ctx context.Context) ([]*entity.ReactionType, error) {
-	rows, err := r.db.QueryContext(ctx, `SELECT reaction_type_id, name, image FROM reaction_types ORDER BY reaction_type_id`)
+// List returns all reaction types, retired ones only when includeRetired is set
+func (r *ReactionTypeRepository) List(ctx context.Context, includeRetired bool) (_ []*entity.ReactionType, err error) {
+	defer observe(ctx, "ReactionTypeRepository", "List")(&err)
+
+	const q = `
+        SELECT reaction_type_id, name, image, retired_at
+        FROM reaction_types
+        WHERE $1 OR retired_at IS NULL
+        ORDER BY reaction_type_id
+    `
+	rows, err := r.db.QueryContext(ctx, q, includeRetired)
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +66,7 @@ func (r *ReactionTypeRepository) List(ctx context.Context) ([]*entity.ReactionTy
 
 	var list []*entity.ReactionType
 	for rows.Next() {
-		rt, err := scanReactionType(rows)
+		rt, err := scanManagedReactionType(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -71,6 +78,84 @@ func (r *ReactionTypeRepository) List(ctx context.Context) ([]*entity.ReactionTy
 	return list, nil
 }
 
+// Update changes the name and image of a reaction type
+func (r *ReactionTypeRepository) Update(ctx context.Context, rt *entity.ReactionType) (err error) {
+	defer observe(ctx, "ReactionTypeRepository", "Update")(&err)
+
+	const q = `
+        UPDATE reaction_types
+        SET name = $2, image = $3
+        WHERE reaction_type_id = $1
+    `
+	res, err := r.db.ExecContext(ctx, q, rt.ID, rt.Name, nullableImage(rt.Image))
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Retire hides a reaction type from new reactions
+// The row is kept since existing reactions still reference it
+func (r *ReactionTypeRepository) Retire(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "ReactionTypeRepository", "Retire")(&err)
+
+	const q = `
+        UPDATE reaction_types
+        SET retired_at = COALESCE(retired_at, NOW())
+        WHERE reaction_type_id = $1
+    `
+	res, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Restore lets a retired reaction type accept new reactions again
+func (r *ReactionTypeRepository) Restore(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "ReactionTypeRepository", "Restore")(&err)
+
+	const q = `
+        UPDATE reaction_types
+        SET retired_at = NULL
+        WHERE reaction_type_id = $1
+    `
+	res, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// nullableImage maps an empty image to NULL
+func nullableImage(image *string) sql.NullString {
+	if image == nil || *image == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *image, Valid: true}
+}
+
 // reactionTypeRowScanner defines the interface for scanning reaction type rows
 type reactionTypeRowScanner interface {
 	Scan(dest ...any) error
@@ -94,3 +179,16 @@ func scanReactionType(rs reactionTypeRowScanner) (*entity.ReactionType, error) {
 	}
 	return &rt, nil
 }
+
+// scanManagedReactionType scans a reaction type followed by its retirement time
+func scanManagedReactionType(rs reactionTypeRowScanner) (*entity.ReactionType, error) {
+	var retiredAt sql.NullTime
+	rt, err := scanReactionType(extraColumnsScanner{rs: rs, extra: []any{&retiredAt}})
+	if err != nil {
+		return nil, err
+	}
+	if retiredAt.Valid {
+		rt.RetiredAt = &retiredAt.Time
+	}
+	return rt, nil
+}
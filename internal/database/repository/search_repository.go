@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"my-chi-app/internal/domain/entity"
+)
+
+// headlineOptions controls the highlighted snippets returned with search results
+const headlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" ... "`
+
+// Text passed to ts_headline with its HTML special characters escaped
+// Snippets are rendered as HTML to show the <mark> highlights, so the user text must not carry markup of its own
+const (
+	postSnippetText    = `replace(replace(replace(replace(p.headline || ' ' || coalesce(p.text, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;')`
+	commentSnippetText = `replace(replace(replace(replace(c.text, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;')`
+)
+
+// SearchFilter narrows down a full-text search
+// Nil fields are not applied
+type SearchFilter struct {
+	Query      string
+	CategoryID *int64
+	AuthorID   *int64
+	From       *time.Time
+	To         *time.Time
+}
+
+// SearchCursor marks a position in search results ordered by rank and ID
+type SearchCursor struct {
+	Rank float64
+	ID   int64
+}
+
+// searchCursorArgs expands a search cursor into its query arguments, both NULL when there is no cursor
+func searchCursorArgs(after *SearchCursor) (any, any) {
+	if after == nil {
+		return nil, nil
+	}
+	return after.Rank, after.ID
+}
+
+// PostHit is a post matching a search with its rank and highlighted snippet
+type PostHit struct {
+	Post    *entity.Post
+	Rank    float64
+	Snippet string
+}
+
+// CommentHit is a comment matching a search with its rank and highlighted snippet
+type CommentHit struct {
+	Comment *entity.Comment
+	Rank    float64
+	Snippet string
+}
+
+// SearchRepository runs full-text searches over posts and comments
+type SearchRepository struct {
+	db *sql.DB
+}
+
+// NewSearchRepository creates a new SearchRepository
+func NewSearchRepository(db *sql.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// SearchPosts returns posts matching the filter ordered by relevance
+func (r *SearchRepository) SearchPosts(ctx context.Context, f SearchFilter, after *SearchCursor, limit int32) (_ []*PostHit, err error) {
+	defer observe(ctx, "SearchRepository", "SearchPosts")(&err)
+
+	const q = `
+        SELECT p.post_id, p.owner_id, p.category_id, p.headline, p.text, p.image, p.created_at, p.updated_at, p.status,
+               ts_rank_cd(p.search_vector, query)::float8 AS rank,
+               ts_headline('english', ` + postSnippetText + `, query, '` + headlineOptions + `') AS snippet
+        FROM posts p, websearch_to_tsquery('english', $1) query
+        WHERE p.search_vector @@ query
+          AND ($2::bigint IS NULL OR p.category_id = $2)
+          AND ($3::bigint IS NULL OR p.owner_id = $3)
+          AND ($4::timestamptz IS NULL OR p.created_at >= $4)
+          AND ($5::timestamptz IS NULL OR p.created_at < $5)
+          AND ($6::float8 IS NULL OR (ts_rank_cd(p.search_vector, query)::float8, p.post_id) < ($6, $7))
+        ORDER BY rank DESC, p.post_id DESC
+        LIMIT $8
+    `
+	afterRank, afterID := searchCursorArgs(after)
+	rows, err := r.db.QueryContext(ctx, q, f.Query, f.CategoryID, f.AuthorID, f.From, f.To, afterRank, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*PostHit
+	for rows.Next() {
+		var (
+			hit   PostHit
+			p     entity.Post
+			text  sql.NullString
+			image sql.NullString
+		)
+		if err := rows.Scan(&p.ID, &p.OwnerID, &p.CategoryID, &p.Headline, &text, &image, &p.CreatedAt, &p.UpdatedAt, &p.Status, &hit.Rank, &hit.Snippet); err != nil {
+			return nil, err
+		}
+		if text.Valid {
+			p.Text = &text.String
+		}
+		if image.Valid {
+			p.Image = &image.String
+		}
+		hit.Post = &p
+		list = append(list, &hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SearchComments returns comments matching the filter ordered by relevance
+// The category filter applies to the post the comment belongs to
+func (r *SearchRepository) SearchComments(ctx context.Context, f SearchFilter, after *SearchCursor, limit int32) (_ []*CommentHit, err error) {
+	defer observe(ctx, "SearchRepository", "SearchComments")(&err)
+
+	const q = `
+        SELECT c.comment_id, c.post_id, c.owner_id, c.parent_comment_id, c.text, c.image, c.created_at, c.updated_at, c.status,
+               ts_rank_cd(c.search_vector, query)::float8 AS rank,
+               ts_headline('english', ` + commentSnippetText + `, query, '` + headlineOptions + `') AS snippet
+        FROM comments c
+        INNER JOIN posts p ON c.post_id = p.post_id,
+        websearch_to_tsquery('english', $1) query
+        WHERE c.search_vector @@ query
+          AND ($2::bigint IS NULL OR p.category_id = $2)
+          AND ($3::bigint IS NULL OR c.owner_id = $3)
+          AND ($4::timestamptz IS NULL OR c.created_at >= $4)
+          AND ($5::timestamptz IS NULL OR c.created_at < $5)
+          AND ($6::float8 IS NULL OR (ts_rank_cd(c.search_vector, query)::float8, c.comment_id) < ($6, $7))
+        ORDER BY rank DESC, c.comment_id DESC
+        LIMIT $8
+    `
+	afterRank, afterID := searchCursorArgs(after)
+	rows, err := r.db.QueryContext(ctx, q, f.Query, f.CategoryID, f.AuthorID, f.From, f.To, afterRank, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*CommentHit
+	for rows.Next() {
+		var (
+			hit    CommentHit
+			c      entity.Comment
+			parent sql.NullInt64
+			image  sql.NullString
+		)
+		if err := rows.Scan(&c.ID, &c.PostID, &c.OwnerID, &parent, &c.Text, &image, &c.CreatedAt, &c.UpdatedAt, &c.Status, &hit.Rank, &hit.Snippet); err != nil {
+			return nil, err
+		}
+		if parent.Valid {
+			c.ParentCommentID = &parent.Int64
+		}
+		if image.Valid {
+			c.Image = &image.String
+		}
+		hit.Comment = &c
+		list = append(list, &hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Reindex rebuilds the full-text search indexes and refreshes planner statistics
+// With concurrently set the indexes are rebuilt without blocking writes
+func (r *SearchRepository) Reindex(ctx context.Context, concurrently bool) (err error) {
+	defer observe(ctx, "SearchRepository", "Reindex")(&err)
+
+	reindex := `REINDEX INDEX `
+	if concurrently {
+		reindex = `REINDEX INDEX CONCURRENTLY `
+	}
+	for _, index := range []string{"idx_posts_search", "idx_comments_search"} {
+		if _, err := r.db.ExecContext(ctx, reindex+index); err != nil {
+			return err
+		}
+	}
+	_, err = r.db.ExecContext(ctx, `ANALYZE posts, comments`)
+	return err
+}
@@ -20,15 +20,19 @@ func NewTokenRepository(db *sql.DB) *TokenRepository {
 }
 
 // Create inserts a new token into the database
-func (r *TokenRepository) Create(ctx context.Context, t *entity.Token) (*entity.Token, error) {
+// A token continuing an existing session keeps the creation time of that session
+func (r *TokenRepository) Create(ctx context.Context, t *entity.Token) (_ *entity.Token, err error) {
+	defer observe(ctx, "TokenRepository", "Create")(&err)
+
 	const q = `
-        INSERT INTO tokens (user_id, token, expires_at)
-        VALUES ($1, $2, $3)
-        RETURNING token_id, expires_at
+        INSERT INTO tokens (user_id, token, expires_at, session_id, refresh_token_hash, refresh_expires_at, user_agent, ip_address, session_created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+                COALESCE((SELECT MIN(session_created_at) FROM tokens WHERE user_id = $1 AND session_id = $4), NOW()))
+        RETURNING token_id, expires_at, created_at, last_used_at, session_created_at
     `
 
-	err := r.db.QueryRowContext(ctx, q, t.UserID, t.Token, t.ExpiresAt).
-		Scan(&t.ID, &t.ExpiresAt)
+	err = r.db.QueryRowContext(ctx, q, t.UserID, t.Token, t.ExpiresAt, t.SessionID, t.RefreshTokenHash, t.RefreshExpiresAt, t.UserAgent, t.IPAddress).
+		Scan(&t.ID, &t.ExpiresAt, &t.CreatedAt, &t.LastUsedAt, &t.SessionCreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -36,9 +40,11 @@ func (r *TokenRepository) Create(ctx context.Context, t *entity.Token) (*entity.
 }
 
 // GetByToken retrieves a token by its string value
-func (r *TokenRepository) GetByToken(ctx context.Context, token string) (*entity.Token, error) {
+func (r *TokenRepository) GetByToken(ctx context.Context, token string) (_ *entity.Token, err error) {
+	defer observe(ctx, "TokenRepository", "GetByToken")(&err)
+
 	const q = `
-        SELECT token_id, user_id, token, expires_at
+        SELECT token_id, user_id, token, expires_at, session_id, refresh_token_hash, refresh_expires_at, user_agent, ip_address, created_at, last_used_at, rotated_at, session_created_at
         FROM tokens
         WHERE token = $1
     `
@@ -46,8 +52,81 @@ func (r *TokenRepository) GetByToken(ctx context.Context, token string) (*entity
 	return scanToken(row)
 }
 
+// GetByRefreshTokenHash retrieves a token by the hash of its refresh token
+func (r *TokenRepository) GetByRefreshTokenHash(ctx context.Context, hash string) (_ *entity.Token, err error) {
+	defer observe(ctx, "TokenRepository", "GetByRefreshTokenHash")(&err)
+
+	const q = `
+        SELECT token_id, user_id, token, expires_at, session_id, refresh_token_hash, refresh_expires_at, user_agent, ip_address, created_at, last_used_at, rotated_at, session_created_at
+        FROM tokens
+        WHERE refresh_token_hash = $1
+    `
+	row := r.db.QueryRowContext(ctx, q, hash)
+	return scanToken(row)
+}
+
+// ListActiveByUser returns the current, non-rotated token of every live session of a user
+func (r *TokenRepository) ListActiveByUser(ctx context.Context, userID int64) (_ []*entity.Token, err error) {
+	defer observe(ctx, "TokenRepository", "ListActiveByUser")(&err)
+
+	const q = `
+        SELECT token_id, user_id, token, expires_at, session_id, refresh_token_hash, refresh_expires_at, user_agent, ip_address, created_at, last_used_at, rotated_at, session_created_at
+        FROM tokens
+        WHERE user_id = $1 AND rotated_at IS NULL AND COALESCE(refresh_expires_at, expires_at) > NOW()
+        ORDER BY last_used_at DESC
+    `
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*entity.Token
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// MarkRotated flags a token as replaced by a refresh
+// Returns sql.ErrNoRows if the token was already rotated, which signals refresh token reuse
+func (r *TokenRepository) MarkRotated(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "TokenRepository", "MarkRotated")(&err)
+
+	res, err := r.db.ExecContext(ctx, `UPDATE tokens SET rotated_at = NOW() WHERE token_id = $1 AND rotated_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// TouchLastUsed records activity on a token, at most once per minute to limit writes
+func (r *TokenRepository) TouchLastUsed(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "TokenRepository", "TouchLastUsed")(&err)
+
+	const q = `UPDATE tokens SET last_used_at = NOW() WHERE token_id = $1 AND last_used_at < NOW() - INTERVAL '1 minute'`
+	_, err = r.db.ExecContext(ctx, q, id)
+	return err
+}
+
 // DeleteByID removes a token by its ID
-func (r *TokenRepository) DeleteByID(ctx context.Context, id int64) error {
+func (r *TokenRepository) DeleteByID(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "TokenRepository", "DeleteByID")(&err)
+
 	res, err := r.db.ExecContext(ctx, `DELETE FROM tokens WHERE token_id = $1`, id)
 	if err != nil {
 		return err
@@ -62,9 +141,51 @@ func (r *TokenRepository) DeleteByID(ctx context.Context, id int64) error {
 	return nil
 }
 
-// PurgeExpired deletes all tokens that have expired before the cutoff time
-func (r *TokenRepository) PurgeExpired(ctx context.Context, cutoff time.Time) (int64, error) {
-	res, err := r.db.ExecContext(ctx, `DELETE FROM tokens WHERE expires_at < $1`, cutoff)
+// DeleteBySession removes every token of a session, including rotated ones
+func (r *TokenRepository) DeleteBySession(ctx context.Context, userID int64, sessionID string) (err error) {
+	defer observe(ctx, "TokenRepository", "DeleteBySession")(&err)
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM tokens WHERE user_id = $1 AND session_id = $2`, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteByUser removes every token of a user and returns the number of deleted tokens
+func (r *TokenRepository) DeleteByUser(ctx context.Context, userID int64) (_ int64, err error) {
+	defer observe(ctx, "TokenRepository", "DeleteByUser")(&err)
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DeleteByUserExceptSession removes every token of a user outside the given session
+func (r *TokenRepository) DeleteByUserExceptSession(ctx context.Context, userID int64, sessionID string) (_ int64, err error) {
+	defer observe(ctx, "TokenRepository", "DeleteByUserExceptSession")(&err)
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM tokens WHERE user_id = $1 AND session_id <> $2`, userID, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PurgeExpired deletes all tokens that can no longer be used or refreshed before the cutoff time
+func (r *TokenRepository) PurgeExpired(ctx context.Context, cutoff time.Time) (_ int64, err error) {
+	defer observe(ctx, "TokenRepository", "PurgeExpired")(&err)
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM tokens WHERE COALESCE(refresh_expires_at, expires_at) < $1`, cutoff)
 	if err != nil {
 		return 0, err
 	}
@@ -78,12 +199,26 @@ type tokenRowScanner interface {
 
 // scanToken scans a token from the given row scanner
 func scanToken(rs tokenRowScanner) (*entity.Token, error) {
-	var t entity.Token
-	if err := rs.Scan(&t.ID, &t.UserID, &t.Token, &t.ExpiresAt); err != nil {
+	var (
+		t              entity.Token
+		refreshHash    sql.NullString
+		refreshExpires sql.NullTime
+		rotated        sql.NullTime
+	)
+	if err := rs.Scan(&t.ID, &t.UserID, &t.Token, &t.ExpiresAt, &t.SessionID, &refreshHash, &refreshExpires, &t.UserAgent, &t.IPAddress, &t.CreatedAt, &t.LastUsedAt, &rotated, &t.SessionCreatedAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, sql.ErrNoRows
 		}
 		return nil, err
 	}
+	if refreshHash.Valid {
+		t.RefreshTokenHash = &refreshHash.String
+	}
+	if refreshExpires.Valid {
+		t.RefreshExpiresAt = &refreshExpires.Time
+	}
+	if rotated.Valid {
+		t.RotatedAt = &rotated.Time
+	}
 	return &t, nil
 }
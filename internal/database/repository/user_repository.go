@@ -6,6 +6,8 @@ import (
 	"errors"
 
 	"my-chi-app/internal/domain/entity"
+
+	"github.com/lib/pq"
 )
 
 // UserRepository provides CRUD operations for users
@@ -19,11 +21,13 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 // Create inserts a new user and returns the created user data
-func (r *UserRepository) Create(ctx context.Context, u *entity.User) (*entity.User, error) {
+func (r *UserRepository) Create(ctx context.Context, u *entity.User) (_ *entity.User, err error) {
+	defer observe(ctx, "UserRepository", "Create")(&err)
+
 	const q = `
         INSERT INTO users (username, email, password, profile_picture)
         VALUES ($1, $2, $3, $4)
-        RETURNING user_id, created_at
+        RETURNING user_id, role, created_at
     `
 
 	var profile *string
@@ -31,8 +35,8 @@ func (r *UserRepository) Create(ctx context.Context, u *entity.User) (*entity.Us
 		profile = u.ProfilePicture
 	}
 
-	err := r.db.QueryRowContext(ctx, q, u.Username, u.Email, u.Password, profile).
-		Scan(&u.ID, &u.CreatedAt)
+	err = r.db.QueryRowContext(ctx, q, u.Username, u.Email, u.Password, profile).
+		Scan(&u.ID, &u.Role, &u.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -41,9 +45,11 @@ func (r *UserRepository) Create(ctx context.Context, u *entity.User) (*entity.Us
 }
 
 // GetByID returns a user by primary key
-func (r *UserRepository) GetByID(ctx context.Context, id int64) (*entity.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (_ *entity.User, err error) {
+	defer observe(ctx, "UserRepository", "GetByID")(&err)
+
 	const q = `
-        SELECT user_id, username, email, password, profile_picture, created_at
+        SELECT user_id, username, email, password, profile_picture, role, email_verified_at, created_at
         FROM users
         WHERE user_id = $1
     `
@@ -52,10 +58,42 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*entity.User, e
 	return scanUser(row)
 }
 
+// GetByIDs returns the users with the given IDs keyed by ID
+// Unknown IDs are missing from the map
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []int64) (_ map[int64]*entity.User, err error) {
+	defer observe(ctx, "UserRepository", "GetByIDs")(&err)
+
+	const q = `
+        SELECT user_id, username, email, password, profile_picture, role, email_verified_at, created_at
+        FROM users
+        WHERE user_id = ANY($1)
+    `
+	rows, err := r.db.QueryContext(ctx, q, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[int64]*entity.User, len(ids))
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users[u.ID] = u
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // GetByEmail returns a user matching the email
-func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (_ *entity.User, err error) {
+	defer observe(ctx, "UserRepository", "GetByEmail")(&err)
+
 	const q = `
-        SELECT user_id, username, email, password, profile_picture, created_at
+        SELECT user_id, username, email, password, profile_picture, role, email_verified_at, created_at
         FROM users
         WHERE email = $1
     `
@@ -65,9 +103,11 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*entity.
 }
 
 // GetByUsername returns a user matching the username
-func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (_ *entity.User, err error) {
+	defer observe(ctx, "UserRepository", "GetByUsername")(&err)
+
 	const q = `
-        SELECT user_id, username, email, password, profile_picture, created_at
+        SELECT user_id, username, email, password, profile_picture, role, email_verified_at, created_at
         FROM users
         WHERE username = $1
     `
@@ -77,9 +117,11 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*e
 }
 
 // List returns users ordered by newest first with pagination
-func (r *UserRepository) List(ctx context.Context, limit, offset int32) ([]*entity.User, error) {
+func (r *UserRepository) List(ctx context.Context, limit, offset int32) (_ []*entity.User, err error) {
+	defer observe(ctx, "UserRepository", "List")(&err)
+
 	const q = `
-        SELECT user_id, username, email, password, profile_picture, created_at
+        SELECT user_id, username, email, password, profile_picture, role, email_verified_at, created_at
         FROM users
         ORDER BY user_id DESC
         LIMIT $1 OFFSET $2
@@ -108,7 +150,9 @@ func (r *UserRepository) List(ctx context.Context, limit, offset int32) ([]*enti
 }
 
 // Delete removes a user by ID
-func (r *UserRepository) Delete(ctx context.Context, id int64) error {
+func (r *UserRepository) Delete(ctx context.Context, id int64) (err error) {
+	defer observe(ctx, "UserRepository", "Delete")(&err)
+
 	const q = `DELETE FROM users WHERE user_id = $1`
 	res, err := r.db.ExecContext(ctx, q, id)
 	if err != nil {
@@ -125,7 +169,9 @@ func (r *UserRepository) Delete(ctx context.Context, id int64) error {
 }
 
 // UpdateProfilePicture updates user's profile picture
-func (r *UserRepository) UpdateProfilePicture(ctx context.Context, userID int64, picture string) error {
+func (r *UserRepository) UpdateProfilePicture(ctx context.Context, userID int64, picture string) (err error) {
+	defer observe(ctx, "UserRepository", "UpdateProfilePicture")(&err)
+
 	const q = `UPDATE users SET profile_picture = NULLIF($2, '') WHERE user_id = $1`
 	res, err := r.db.ExecContext(ctx, q, userID, picture)
 	if err != nil {
@@ -142,7 +188,9 @@ func (r *UserRepository) UpdateProfilePicture(ctx context.Context, userID int64,
 }
 
 // UpdateUsername updates user's username
-func (r *UserRepository) UpdateUsername(ctx context.Context, userID int64, username string) error {
+func (r *UserRepository) UpdateUsername(ctx context.Context, userID int64, username string) (err error) {
+	defer observe(ctx, "UserRepository", "UpdateUsername")(&err)
+
 	const q = `UPDATE users SET username = $2 WHERE user_id = $1`
 	res, err := r.db.ExecContext(ctx, q, userID, username)
 	if err != nil {
@@ -158,6 +206,82 @@ func (r *UserRepository) UpdateUsername(ctx context.Context, userID int64, usern
 	return nil
 }
 
+// UpdateRole updates user's global role
+func (r *UserRepository) UpdateRole(ctx context.Context, userID int64, role string) (err error) {
+	defer observe(ctx, "UserRepository", "UpdateRole")(&err)
+
+	const q = `UPDATE users SET role = $2 WHERE user_id = $1`
+	res, err := r.db.ExecContext(ctx, q, userID, role)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdatePassword updates user's password hash
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int64, passwordHash string) (err error) {
+	defer observe(ctx, "UserRepository", "UpdatePassword")(&err)
+
+	const q = `UPDATE users SET password = $2 WHERE user_id = $1`
+	res, err := r.db.ExecContext(ctx, q, userID, passwordHash)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateEmail updates user's email, the new address counts as verified since it was confirmed
+func (r *UserRepository) UpdateEmail(ctx context.Context, userID int64, email string) (err error) {
+	defer observe(ctx, "UserRepository", "UpdateEmail")(&err)
+
+	const q = `UPDATE users SET email = $2, email_verified_at = NOW() WHERE user_id = $1`
+	res, err := r.db.ExecContext(ctx, q, userID, email)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// MarkEmailVerified records that the user proved ownership of their email
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID int64) (err error) {
+	defer observe(ctx, "UserRepository", "MarkEmailVerified")(&err)
+
+	const q = `UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE user_id = $1`
+	res, err := r.db.ExecContext(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // rowScanner defines the interface for scanning user rows
 type rowScanner interface {
 	Scan(dest ...any) error
@@ -166,11 +290,12 @@ type rowScanner interface {
 // scanUser scans a user from the given row scanner
 func scanUser(rs rowScanner) (*entity.User, error) {
 	var (
-		u       entity.User
-		profile sql.NullString
+		u        entity.User
+		profile  sql.NullString
+		verified sql.NullTime
 	)
 
-	if err := rs.Scan(&u.ID, &u.Username, &u.Email, &u.Password, &profile, &u.CreatedAt); err != nil {
+	if err := rs.Scan(&u.ID, &u.Username, &u.Email, &u.Password, &profile, &u.Role, &verified, &u.CreatedAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, sql.ErrNoRows
 		}
@@ -180,6 +305,9 @@ func scanUser(rs rowScanner) (*entity.User, error) {
 	if profile.Valid {
 		u.ProfilePicture = &profile.String
 	}
+	if verified.Valid {
+		u.EmailVerifiedAt = &verified.Time
+	}
 
 	return &u, nil
 }
@@ -0,0 +1,385 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+	"my-chi-app/internal/mail"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Lifetimes of the single-use account tokens
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 48 * time.Hour
+	emailChangeTTL       = 24 * time.Hour
+)
+
+// ForgotPasswordRequest is the payload request for starting a password reset
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest is the payload request for choosing a new password with a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// VerifyEmailRequest is the payload request for confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// ConfirmEmailChangeRequest is the payload request for confirming a new email address
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+// Swagger annotations:
+// @Summary Request a password reset
+// @Description Email a single-use password reset link if an account uses this address.
+// @Description Always succeeds so the endpoint cannot be used to discover accounts.
+// @Tags auth
+// @Param request body ForgotPasswordRequest true "Account email"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/password/forgot [post]
+func HandleForgotPassword(userRepo *repository.UserRepository, accountTokenRepo *repository.AccountTokenRepository, mailer mail.Mailer, appBaseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ForgotPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "invalid request body")
+			return
+		}
+
+		if req.Email == "" || !isValidEmail(req.Email) {
+			ValidationError(w, "a valid email is required")
+			return
+		}
+
+		ctx := r.Context()
+		response := MessageResponse{
+			Message: "If an account exists for this email, a reset link has been sent.",
+		}
+
+		user, err := userRepo.GetByEmail(ctx, req.Email)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				Success(w, response)
+				return
+			}
+			InternalError(w, r, "failed to fetch user", err)
+			return
+		}
+
+		token, err := issueAccountToken(ctx, accountTokenRepo, user.ID, entity.AccountTokenPasswordReset, nil, passwordResetTTL)
+		if err != nil {
+			InternalError(w, r, "failed to create reset token", err)
+			return
+		}
+
+		sendAccountEmail(ctx, mailer, mail.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s and can only be used once.\n\n%s\n\nIf you did not ask for this, you can ignore this email.\n",
+				user.Username, passwordResetTTL, accountLink(appBaseURL, "/reset-password", token)),
+		})
+
+		Success(w, response)
+	}
+}
+
+// Swagger annotations:
+// @Summary Reset password
+// @Description Set a new password with a reset token and log out every session
+// @Tags auth
+// @Param request body ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/password/reset [post]
+func HandleResetPassword(userRepo *repository.UserRepository, tokenRepo *repository.TokenRepository, accountTokenRepo *repository.AccountTokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ResetPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "invalid request body")
+			return
+		}
+
+		if req.Token == "" {
+			ValidationError(w, "token is required")
+			return
+		}
+		if len(req.Password) < 8 {
+			ValidationError(w, "password must be at least 8 characters")
+			return
+		}
+
+		ctx := r.Context()
+
+		t, err := accountTokenRepo.Consume(ctx, entity.AccountTokenPasswordReset, hashToken(req.Token))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				Unauthorized(w, "invalid or expired token")
+				return
+			}
+			InternalError(w, r, "failed to verify token", err)
+			return
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			InternalError(w, r, "failed to hash password", err)
+			return
+		}
+
+		if err := userRepo.UpdatePassword(ctx, t.UserID, string(hashedPassword)); err != nil {
+			InternalError(w, r, "failed to update password", err)
+			return
+		}
+
+		// Receiving the reset email proves ownership of the address
+		if err := userRepo.MarkEmailVerified(ctx, t.UserID); err != nil {
+			InternalError(w, r, "failed to update user", err)
+			return
+		}
+
+		if _, err := tokenRepo.DeleteByUser(ctx, t.UserID); err != nil {
+			InternalError(w, r, "failed to revoke sessions", err)
+			return
+		}
+
+		Success(w, MessageResponse{
+			Message: "Password reset successfully!",
+		})
+	}
+}
+
+// Swagger annotations:
+// @Summary Verify email
+// @Description Confirm ownership of the account email with a verification token
+// @Tags auth
+// @Param request body VerifyEmailRequest true "Verification token"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/email/verify [post]
+func HandleVerifyEmail(userRepo *repository.UserRepository, accountTokenRepo *repository.AccountTokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req VerifyEmailRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "invalid request body")
+			return
+		}
+
+		if req.Token == "" {
+			ValidationError(w, "token is required")
+			return
+		}
+
+		ctx := r.Context()
+
+		t, err := accountTokenRepo.Consume(ctx, entity.AccountTokenEmailVerification, hashToken(req.Token))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				Unauthorized(w, "invalid or expired token")
+				return
+			}
+			InternalError(w, r, "failed to verify token", err)
+			return
+		}
+
+		if err := userRepo.MarkEmailVerified(ctx, t.UserID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "user not found")
+				return
+			}
+			InternalError(w, r, "failed to verify email", err)
+			return
+		}
+
+		Success(w, MessageResponse{
+			Message: "Email verified successfully!",
+		})
+	}
+}
+
+// Swagger annotations:
+// @Summary Resend verification email
+// @Description Send a new email verification link to the authenticated user
+// @Tags auth
+// @Security Bearer
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/email/verify/resend [post]
+func HandleResendVerificationEmail(userRepo *repository.UserRepository, accountTokenRepo *repository.AccountTokenRepository, mailer mail.Mailer, appBaseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			Unauthorized(w, "user not authenticated")
+			return
+		}
+
+		ctx := r.Context()
+
+		user, err := userRepo.GetByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "user not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch user", err)
+			return
+		}
+
+		if user.EmailVerifiedAt != nil {
+			Conflict(w, "email already verified")
+			return
+		}
+
+		if err := sendVerificationEmail(ctx, accountTokenRepo, mailer, appBaseURL, user); err != nil {
+			InternalError(w, r, "failed to create verification token", err)
+			return
+		}
+
+		Success(w, MessageResponse{
+			Message: "Verification email sent!",
+		})
+	}
+}
+
+// Swagger annotations:
+// @Summary Confirm email change
+// @Description Switch the account to the new email address with the token sent to that address
+// @Tags auth
+// @Param request body ConfirmEmailChangeRequest true "Email change token"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/email/change/confirm [post]
+func HandleConfirmEmailChange(userRepo *repository.UserRepository, accountTokenRepo *repository.AccountTokenRepository, mailer mail.Mailer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ConfirmEmailChangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "invalid request body")
+			return
+		}
+
+		if req.Token == "" {
+			ValidationError(w, "token is required")
+			return
+		}
+
+		ctx := r.Context()
+
+		t, err := accountTokenRepo.Consume(ctx, entity.AccountTokenEmailChange, hashToken(req.Token))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				Unauthorized(w, "invalid or expired token")
+				return
+			}
+			InternalError(w, r, "failed to verify token", err)
+			return
+		}
+		if t.Payload == nil {
+			Unauthorized(w, "invalid or expired token")
+			return
+		}
+
+		user, err := userRepo.GetByID(ctx, t.UserID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "user not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch user", err)
+			return
+		}
+
+		if err := userRepo.UpdateEmail(ctx, user.ID, *t.Payload); err != nil {
+			if isDuplicateError(err) {
+				Conflict(w, "email already exists")
+				return
+			}
+			InternalError(w, r, "failed to update email", err)
+			return
+		}
+
+		// Let the previous address know in case the change was not wanted
+		sendAccountEmail(ctx, mailer, mail.Message{
+			To:      user.Email,
+			Subject: "Your email address was changed",
+			Body:    fmt.Sprintf("Hi %s,\n\nThe email address of your account was changed to %s.\nIf you did not do this, reset your password immediately.\n", user.Username, *t.Payload),
+		})
+
+		Success(w, MessageResponse{
+			Message: "Email changed successfully!",
+		})
+	}
+}
+
+// sendVerificationEmail issues a verification token for the user and emails it
+func sendVerificationEmail(ctx context.Context, accountTokenRepo *repository.AccountTokenRepository, mailer mail.Mailer, appBaseURL string, user *entity.User) error {
+	token, err := issueAccountToken(ctx, accountTokenRepo, user.ID, entity.AccountTokenEmailVerification, nil, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	sendAccountEmail(ctx, mailer, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address with the link below. It expires in %s.\n\n%s\n",
+			user.Username, emailVerificationTTL, accountLink(appBaseURL, "/verify-email", token)),
+	})
+	return nil
+}
+
+// issueAccountToken creates a single-use token and stores only its hash
+func issueAccountToken(ctx context.Context, accountTokenRepo *repository.AccountTokenRepository, userID int64, purpose string, payload *string, ttl time.Duration) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	t := &entity.AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		Payload:   payload,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if _, err := accountTokenRepo.Create(ctx, t); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendAccountEmail hands the message to the mailer, a failure is logged and never fails the request
+// The server sends through a mail.BackgroundMailer so the request does not wait for the mail server
+func sendAccountEmail(ctx context.Context, mailer mail.Mailer, msg mail.Message) {
+	if err := mailer.Send(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "failed to send account email", "subject", msg.Subject, "error", err)
+	}
+}
+
+// accountLink builds the frontend link carrying an account token
+func accountLink(appBaseURL, path, token string) string {
+	return appBaseURL + path + "?token=" + url.QueryEscape(token)
+}
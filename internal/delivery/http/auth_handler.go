@@ -3,21 +3,30 @@ package http
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"my-chi-app/internal/database/repository"
 	"my-chi-app/internal/domain/entity"
+	"my-chi-app/internal/mail"
+	"my-chi-app/internal/metrics"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// dummyPasswordHash is compared against for unknown accounts so they take as long to reject as a wrong password
+// It is a bcrypt hash at bcrypt.DefaultCost of a password nobody uses
+const dummyPasswordHash = "$2a$10$NpAsCEyraLNWOPgj4.5hzekajd6YHurWBKaXHCqroykIQ3IDdQnyq"
+
 // RegisterRequest is the payload request for registering a new user
 type RegisterRequest struct {
 	Username   string     `json:"username"`
@@ -33,15 +42,41 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+// RefreshRequest is the payload request for exchanging a refresh token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 // UserResponse is the response returned if registration or login is successful
 type UserResponse struct {
 	UserID         int64     `json:"user_id"`
 	Username       string    `json:"username"`
 	Email          string    `json:"email"`
-	Password       string    `json:"password"`
 	ProfilePicture *string   `json:"profile_picture,omitempty"`
 	JoinedDate     time.Time `json:"joined_date"`
 	Token          string    `json:"token"`
+	RefreshToken   string    `json:"refresh_token,omitempty"`
+}
+
+// TokenResponse is the response returned when a session is refreshed
+type TokenResponse struct {
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// TokenConfig holds the signing secret and lifetimes of the credentials issued for a session
+type TokenConfig struct {
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// authTokens holds the credentials issued for a session
+type authTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
 }
 
 // LogoutResponse is the payload response for logging out a user
@@ -51,13 +86,15 @@ type LogoutResponse struct {
 
 // Swagger annotations:
 // @Summary Register a new user
-// @Description Create a new user account with username, email, and password and return the user details with JWT token
+// @Description Create a new user account with username, email, and password and return the user details with JWT token.
+// @Description A verification link is emailed to the new account.
 // @Tags auth
 // @Param request body RegisterRequest true "Registration data"
 // @Success 200 {object} UserResponse
 // @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /auth/register [post]
-func HandleRegister(userRepo *repository.UserRepository, tokenRepo *repository.TokenRepository, jwtSecret string) http.HandlerFunc {
+func HandleRegister(userRepo *repository.UserRepository, tokenRepo *repository.TokenRepository, accountTokenRepo *repository.AccountTokenRepository, mailer mail.Mailer, appBaseURL string, tokenConfig TokenConfig, appMetrics *metrics.Metrics) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req RegisterRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -80,7 +117,7 @@ func HandleRegister(userRepo *repository.UserRepository, tokenRepo *repository.T
 
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 		if err != nil {
-			InternalError(w, "failed to hash password")
+			InternalError(w, r, "failed to hash password", err)
 			return
 		}
 
@@ -97,13 +134,18 @@ func HandleRegister(userRepo *repository.UserRepository, tokenRepo *repository.T
 				Conflict(w, "email or username already exists")
 				return
 			}
-			InternalError(w, "failed to create user")
+			InternalError(w, r, "failed to create user", err)
 			return
 		}
+		appMetrics.UserRegistered()
+
+		if err := sendVerificationEmail(ctx, accountTokenRepo, mailer, appBaseURL, user); err != nil {
+			Logger(ctx).Error("failed to send verification email", "user_id", user.ID, "error", err)
+		}
 
-		token, _, err := createToken(ctx, tokenRepo, user.ID, jwtSecret)
+		tokens, err := createToken(ctx, tokenRepo, user.ID, tokenConfig, "", r)
 		if err != nil {
-			InternalError(w, "failed to create token")
+			InternalError(w, r, "failed to create token", err)
 			return
 		}
 
@@ -111,10 +153,10 @@ func HandleRegister(userRepo *repository.UserRepository, tokenRepo *repository.T
 			UserID:         user.ID,
 			Username:       user.Username,
 			Email:          user.Email,
-			Password:       user.Password,
 			ProfilePicture: user.ProfilePicture,
 			JoinedDate:     user.CreatedAt,
-			Token:          token,
+			Token:          tokens.AccessToken,
+			RefreshToken:   tokens.RefreshToken,
 		})
 	}
 }
@@ -127,8 +169,9 @@ func HandleRegister(userRepo *repository.UserRepository, tokenRepo *repository.T
 // @Success 200 {object} UserResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /auth/login [post]
-func HandleLogin(userRepo *repository.UserRepository, tokenRepo *repository.TokenRepository, jwtSecret string) http.HandlerFunc {
+func HandleLogin(userRepo *repository.UserRepository, tokenRepo *repository.TokenRepository, tokenConfig TokenConfig, limiter *RateLimiter, appMetrics *metrics.Metrics) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req LoginRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -155,23 +198,45 @@ func HandleLogin(userRepo *repository.UserRepository, tokenRepo *repository.Toke
 			user, err = userRepo.GetByUsername(ctx, req.Username)
 		}
 
-		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				Unauthorized(w, "invalid credentials")
-				return
-			}
-			InternalError(w, "failed to fetch user")
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			InternalError(w, r, "failed to fetch user", err)
+			return
+		}
+
+		// Unknown accounts are locked out like existing ones so lockouts do not reveal which accounts exist
+		identifier := req.Email
+		if identifier == "" {
+			identifier = req.Username
+		}
+		lockKey := "login:name:" + strings.ToLower(identifier)
+		if user != nil {
+			lockKey = "login:user:" + strconv.FormatInt(user.ID, 10)
+		}
+		if lockedFor := limiter.loginLockedFor(ctx, lockKey); lockedFor > 0 {
+			TooManyRequests(w, lockedFor, "too many failed login attempts, try again later")
+			return
+		}
+
+		if user == nil {
+			bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(req.Password))
+			limiter.loginFailed(ctx, lockKey)
+			appMetrics.LoginAttempted(false)
+			Unauthorized(w, "invalid credentials")
 			return
 		}
 
 		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+			limiter.loginFailed(ctx, lockKey)
+			appMetrics.LoginAttempted(false)
 			Unauthorized(w, "invalid credentials")
 			return
 		}
+		limiter.loginSucceeded(ctx, lockKey)
+		appMetrics.LoginAttempted(true)
 
-		token, _, err := createToken(ctx, tokenRepo, user.ID, jwtSecret)
+		tokens, err := createToken(ctx, tokenRepo, user.ID, tokenConfig, "", r)
 		if err != nil {
-			InternalError(w, "failed to create token")
+			InternalError(w, r, "failed to create token", err)
 			return
 		}
 
@@ -179,10 +244,10 @@ func HandleLogin(userRepo *repository.UserRepository, tokenRepo *repository.Toke
 			UserID:         user.ID,
 			Username:       user.Username,
 			Email:          user.Email,
-			Password:       user.Password,
 			ProfilePicture: user.ProfilePicture,
 			JoinedDate:     user.CreatedAt,
-			Token:          token,
+			Token:          tokens.AccessToken,
+			RefreshToken:   tokens.RefreshToken,
 		})
 	}
 }
@@ -212,12 +277,12 @@ func HandleLogOut(tokenRepo *repository.TokenRepository) http.HandlerFunc {
 				Unauthorized(w, "invalid token")
 				return
 			}
-			InternalError(w, "failed to fetch token")
+			InternalError(w, r, "failed to fetch token", err)
 			return
 		}
 
-		if err := tokenRepo.DeleteByID(ctx, t.ID); err != nil {
-			InternalError(w, "failed to delete token")
+		if err := tokenRepo.DeleteBySession(ctx, t.UserID, t.SessionID); err != nil {
+			InternalError(w, r, "failed to delete token", err)
 			return
 		}
 
@@ -227,6 +292,81 @@ func HandleLogOut(tokenRepo *repository.TokenRepository) http.HandlerFunc {
 	}
 }
 
+// Swagger annotations:
+// @Summary Refresh session
+// @Description Exchange a refresh token for a new access token and refresh token.
+// @Description Each refresh token can be used once, reusing a rotated token revokes the whole session.
+// @Tags auth
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/refresh [post]
+func HandleRefreshToken(tokenRepo *repository.TokenRepository, tokenConfig TokenConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "invalid request body")
+			return
+		}
+
+		if req.RefreshToken == "" {
+			ValidationError(w, "refresh_token is required")
+			return
+		}
+
+		ctx := r.Context()
+
+		t, err := tokenRepo.GetByRefreshTokenHash(ctx, hashToken(req.RefreshToken))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				Unauthorized(w, "invalid refresh token")
+				return
+			}
+			InternalError(w, r, "failed to fetch token", err)
+			return
+		}
+
+		if t.RefreshExpiresAt == nil || time.Now().After(*t.RefreshExpiresAt) {
+			Unauthorized(w, "refresh token expired")
+			return
+		}
+
+		// Rotating atomically also catches two concurrent refreshes with the same token
+		reused := t.RotatedAt != nil
+		if !reused {
+			if err := tokenRepo.MarkRotated(ctx, t.ID); err != nil {
+				if !errors.Is(err, sql.ErrNoRows) {
+					InternalError(w, r, "failed to rotate token", err)
+					return
+				}
+				reused = true
+			}
+		}
+		if reused {
+			if err := tokenRepo.DeleteBySession(ctx, t.UserID, t.SessionID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				InternalError(w, r, "failed to revoke session", err)
+				return
+			}
+			Unauthorized(w, "refresh token reuse detected, session revoked")
+			return
+		}
+
+		tokens, err := createToken(ctx, tokenRepo, t.UserID, tokenConfig, t.SessionID, r)
+		if err != nil {
+			InternalError(w, r, "failed to create token", err)
+			return
+		}
+
+		Success(w, TokenResponse{
+			Token:        tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+			ExpiresAt:    tokens.ExpiresAt,
+		})
+	}
+}
+
 // Swagger annotations:
 // @Summary Verify authentication status
 // @Description Check if the current authentication token is valid and return user ID
@@ -252,17 +392,27 @@ func HandleVerifyAuth(userRepo *repository.UserRepository) http.HandlerFunc {
 	}
 }
 
-// createToken generates a JWT token and stores it in the database
-// Token specifies userID as the subject and expires in 24 hours
-func createToken(ctx context.Context, tokenRepo *repository.TokenRepository, userID int64, jwtSecret string) (string, time.Time, error) {
-	b := make([]byte, 16)
-	if _, err := rand.Read(b); err != nil {
-		return "", time.Time{}, err
+// createToken generates a JWT access token with a refresh token and stores them in the database
+// Token specifies userID as the subject and expires after the configured access token lifetime
+// An empty sessionID starts a new session, otherwise the tokens continue the given one
+func createToken(ctx context.Context, tokenRepo *repository.TokenRepository, userID int64, tokenConfig TokenConfig, sessionID string, r *http.Request) (*authTokens, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if sessionID == "" {
+		if sessionID, err = randomToken(16); err != nil {
+			return nil, err
+		}
 	}
-	jti := hex.EncodeToString(b)
 
 	now := time.Now()
-	expiresAt := now.Add(24 * time.Hour)
+	expiresAt := now.Add(tokenConfig.AccessTokenTTL)
+	refreshExpiresAt := now.Add(tokenConfig.RefreshTokenTTL)
 
 	claims := jwt.RegisteredClaims{
 		Subject:   strconv.FormatInt(userID, 10),
@@ -272,22 +422,61 @@ func createToken(ctx context.Context, tokenRepo *repository.TokenRepository, use
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString([]byte(jwtSecret))
+	signed, err := token.SignedString([]byte(tokenConfig.JWTSecret))
 	if err != nil {
-		return "", time.Time{}, err
+		return nil, err
+	}
+
+	refreshHash := hashToken(refreshToken)
+	userAgent := r.UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
 	}
 
 	t := &entity.Token{
-		UserID:    userID,
-		Token:     signed,
-		ExpiresAt: expiresAt,
+		UserID:           userID,
+		Token:            signed,
+		ExpiresAt:        expiresAt,
+		SessionID:        sessionID,
+		RefreshTokenHash: &refreshHash,
+		RefreshExpiresAt: &refreshExpiresAt,
+		UserAgent:        userAgent,
+		IPAddress:        clientIP(r),
 	}
 
 	if _, err := tokenRepo.Create(ctx, t); err != nil {
-		return "", time.Time{}, err
+		return nil, err
+	}
+
+	return &authTokens{
+		AccessToken:  signed,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// randomToken returns n random bytes encoded as hex
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
+	return hex.EncodeToString(b), nil
+}
 
-	return signed, expiresAt, nil
+// hashToken returns the SHA-256 hex digest stored in place of a secret token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// clientIP returns the address of the client that sent the request
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
 
 // extractToken gets bearer token from Authorization header.
@@ -312,6 +501,10 @@ func isValidEmail(email string) bool {
 	at := false
 	dot := false
 	for i := 0; i < len(email); i++ {
+		// Control characters and spaces could inject headers into the emails sent to the address
+		if email[i] <= ' ' || email[i] == 0x7f {
+			return false
+		}
 		if email[i] == '@' {
 			at = true
 		}
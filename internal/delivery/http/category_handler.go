@@ -49,7 +49,7 @@ func HandleGetAllCategories(categoryRepo *repository.CategoryRepository) http.Ha
 
 		categories, err := categoryRepo.List(ctx)
 		if err != nil {
-			InternalError(w, "failed to fetch categories")
+			InternalError(w, r, "failed to fetch categories", err)
 			return
 		}
 
@@ -85,7 +85,7 @@ func HandleGetUserCategories(membershipRepo *repository.MembershipRepository, ca
 
 		memberships, err := membershipRepo.GetByUserID(ctx, userID)
 		if err != nil {
-			InternalError(w, "failed to fetch subscriptions")
+			InternalError(w, r, "failed to fetch subscriptions", err)
 			return
 		}
 
@@ -94,7 +94,7 @@ func HandleGetUserCategories(membershipRepo *repository.MembershipRepository, ca
 			cat, err := categoryRepo.GetByID(ctx, membership.CategoryID)
 			if err != nil {
 				if !errors.Is(err, sql.ErrNoRows) {
-					InternalError(w, "failed to fetch category")
+					InternalError(w, r, "failed to fetch category", err)
 					return
 				}
 				continue
@@ -142,7 +142,7 @@ func HandleGetCategoryByID(categoryRepo *repository.CategoryRepository) http.Han
 				NotFound(w, "category not found")
 				return
 			}
-			InternalError(w, "failed to fetch category")
+			InternalError(w, r, "failed to fetch category", err)
 			return
 		}
 
@@ -155,13 +155,14 @@ func HandleGetCategoryByID(categoryRepo *repository.CategoryRepository) http.Han
 
 // Swagger annotations:
 // @Summary Create a new category
-// @Description Create a new category for the forum
+// @Description Create a new category for the forum (admin only)
 // @Tags categories
 // @Security Bearer
 // @Param request body CreateCategoryRequest true "Category data"
 // @Success 201 {object} CategoryCreatedResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 409 {object} map[string]string
 // @Router /categories [post]
 func HandleCreateCategory(categoryRepo *repository.CategoryRepository) http.HandlerFunc {
@@ -192,7 +193,7 @@ func HandleCreateCategory(categoryRepo *repository.CategoryRepository) http.Hand
 				Conflict(w, "category already exists")
 				return
 			}
-			InternalError(w, "failed to create category")
+			InternalError(w, r, "failed to create category", err)
 			return
 		}
 
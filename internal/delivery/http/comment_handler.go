@@ -11,6 +11,8 @@ import (
 
 	"my-chi-app/internal/database/repository"
 	"my-chi-app/internal/domain/entity"
+	"my-chi-app/internal/metrics"
+	"my-chi-app/internal/notification"
 )
 
 // CreateCommentRequest is the payload for creating a new comment or reply
@@ -32,16 +34,17 @@ type ReactCommentRequest struct {
 
 // CommentResponse is the response shape for comments and replies
 type CommentResponse struct {
-	CommentID            int64         `json:"comment_id"`
-	CommentOwnerUsername string        `json:"comment_owner_username"`
-	ProfilePicture       *string       `json:"comment_owner_profile_picture"`
-	Text                 string        `json:"text"`
-	Image                *string       `json:"image"`
-	CreatedAt            string        `json:"created_at"`
-	UpdatedAt            string        `json:"updated_at"`
-	IsEdited             bool          `json:"is_edited"`
-	TotalReaction        int64         `json:"total_reaction"`
-	UserReaction         *ReactionInfo `json:"user_reaction"`
+	CommentID            int64           `json:"comment_id"`
+	CommentOwnerUsername string          `json:"comment_owner_username"`
+	ProfilePicture       *string         `json:"comment_owner_profile_picture"`
+	Text                 string          `json:"text"`
+	Image                *string         `json:"image"`
+	CreatedAt            string          `json:"created_at"`
+	UpdatedAt            string          `json:"updated_at"`
+	IsEdited             bool            `json:"is_edited"`
+	TotalReaction        int64           `json:"total_reaction"`
+	Reactions            []ReactionCount `json:"reactions"`
+	UserReaction         *ReactionInfo   `json:"user_reaction"`
 }
 
 // @Summary Get comments by post
@@ -49,12 +52,13 @@ type CommentResponse struct {
 // @Tags comments
 // @Security Bearer
 // @Param post_id path int true "Post ID"
-// @Param limit query int false "Limit" default(100)
-// @Param offset query int false "Offset" default(0)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {array} CommentResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /posts/{post_id}/comments [get]
-func HandleGetCommentsByPost(commentRepo *repository.CommentRepository, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository, postRepo *repository.PostRepository) http.HandlerFunc {
+func HandleGetCommentsByPost(commentRepo *repository.CommentRepository, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository, postRepo *repository.PostRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -74,37 +78,31 @@ func HandleGetCommentsByPost(commentRepo *repository.CommentRepository, userRepo
 			if err == sql.ErrNoRows {
 				NotFound(w, "post not found")
 			} else {
-				InternalError(w, err.Error())
+				InternalError(w, r, "failed to fetch post", err)
 			}
 			return
 		}
 
-		// Pagination
-		limit, offset := int32(1000), int32(0)
-		if l := r.URL.Query().Get("limit"); l != "" {
-			if v, err := strconv.ParseInt(l, 10, 32); err == nil {
-				limit = int32(v)
-			}
-		}
-		if o := r.URL.Query().Get("offset"); o != "" {
-			if v, err := strconv.ParseInt(o, 10, 32); err == nil {
-				offset = int32(v)
-			}
+		after, limit, err := parsePagination(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
 		}
 
-		comments, err := commentRepo.ListByPost(r.Context(), postID, limit, offset)
+		comments, err := commentRepo.ListByPost(r.Context(), postID, after, limit+1)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to fetch comments", err)
 			return
 		}
+		comments, next := paginate(comments, limit, commentCursor)
 
-		responses, err := buildCommentResponses(r.Context(), comments, userID, userRepo, commentReactionRepo, reactionTypeRepo)
+		responses, err := buildCommentResponses(r.Context(), comments, userID, userRepo, commentReactionRepo)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to build comment responses", err)
 			return
 		}
 
-		Success(w, responses)
+		Paginated(w, responses, next)
 	}
 }
 
@@ -113,12 +111,13 @@ func HandleGetCommentsByPost(commentRepo *repository.CommentRepository, userRepo
 // @Tags comments
 // @Security Bearer
 // @Param comment_id path int true "Comment ID"
-// @Param limit query int false "Limit" default(1000)
-// @Param offset query int false "Offset" default(0)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {array} CommentResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /comments/{comment_id}/replies [get]
-func HandleGetRepliesByComment(commentRepo *repository.CommentRepository, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+func HandleGetRepliesByComment(commentRepo *repository.CommentRepository, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -138,37 +137,31 @@ func HandleGetRepliesByComment(commentRepo *repository.CommentRepository, userRe
 			if err == sql.ErrNoRows {
 				NotFound(w, "comment not found")
 			} else {
-				InternalError(w, err.Error())
+				InternalError(w, r, "failed to fetch comment", err)
 			}
 			return
 		}
 
-		// Pagination
-		limit, offset := int32(1000), int32(0)
-		if l := r.URL.Query().Get("limit"); l != "" {
-			if v, err := strconv.ParseInt(l, 10, 32); err == nil {
-				limit = int32(v)
-			}
-		}
-		if o := r.URL.Query().Get("offset"); o != "" {
-			if v, err := strconv.ParseInt(o, 10, 32); err == nil {
-				offset = int32(v)
-			}
+		after, limit, err := parsePagination(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
 		}
 
-		replies, err := commentRepo.ListByParent(r.Context(), commentID, limit, offset)
+		replies, err := commentRepo.ListByParent(r.Context(), commentID, after, limit+1)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to fetch comments", err)
 			return
 		}
+		replies, next := paginate(replies, limit, commentCursor)
 
-		responses, err := buildCommentResponses(r.Context(), replies, userID, userRepo, commentReactionRepo, reactionTypeRepo)
+		responses, err := buildCommentResponses(r.Context(), replies, userID, userRepo, commentReactionRepo)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to build comment responses", err)
 			return
 		}
 
-		Success(w, responses)
+		Paginated(w, responses, next)
 	}
 }
 
@@ -176,12 +169,13 @@ func HandleGetRepliesByComment(commentRepo *repository.CommentRepository, userRe
 // @Description Fetch paginated comments of the authenticated user
 // @Tags comments
 // @Security Bearer
-// @Param limit query int false "Limit" default(1000)
-// @Param offset query int false "Offset" default(0)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {array} CommentResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /user/comments [get]
-func HandleGetUserComments(commentRepo *repository.CommentRepository, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+func HandleGetUserComments(commentRepo *repository.CommentRepository, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -189,32 +183,26 @@ func HandleGetUserComments(commentRepo *repository.CommentRepository, userRepo *
 			return
 		}
 
-		// Pagination
-		limit, offset := int32(1000), int32(0)
-		if l := r.URL.Query().Get("limit"); l != "" {
-			if v, err := strconv.ParseInt(l, 10, 32); err == nil {
-				limit = int32(v)
-			}
-		}
-		if o := r.URL.Query().Get("offset"); o != "" {
-			if v, err := strconv.ParseInt(o, 10, 32); err == nil {
-				offset = int32(v)
-			}
+		after, limit, err := parsePagination(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
 		}
 
-		comments, err := commentRepo.ListByOwner(r.Context(), userID, limit, offset)
+		comments, err := commentRepo.ListByOwner(r.Context(), userID, after, limit+1)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to fetch comments", err)
 			return
 		}
+		comments, next := paginate(comments, limit, commentCursor)
 
-		responses, err := buildCommentResponses(r.Context(), comments, userID, userRepo, commentReactionRepo, reactionTypeRepo)
+		responses, err := buildCommentResponses(r.Context(), comments, userID, userRepo, commentReactionRepo)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to build comment responses", err)
 			return
 		}
 
-		Success(w, responses)
+		Paginated(w, responses, next)
 	}
 }
 
@@ -223,12 +211,13 @@ func HandleGetUserComments(commentRepo *repository.CommentRepository, userRepo *
 // @Tags comments
 // @Security Bearer
 // @Param category_id path int true "Category ID"
-// @Param limit query int false "Limit" default(1000)
-// @Param offset query int false "Offset" default(0)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {array} CommentResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /user/comments/category/{category_id} [get]
-func HandleGetUserCommentsByCategory(commentRepo *repository.CommentRepository, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+func HandleGetUserCommentsByCategory(commentRepo *repository.CommentRepository, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -243,33 +232,27 @@ func HandleGetUserCommentsByCategory(commentRepo *repository.CommentRepository,
 			return
 		}
 
-		// Pagination
-		limit, offset := int32(1000), int32(0)
-		if l := r.URL.Query().Get("limit"); l != "" {
-			if v, err := strconv.ParseInt(l, 10, 32); err == nil {
-				limit = int32(v)
-			}
-		}
-		if o := r.URL.Query().Get("offset"); o != "" {
-			if v, err := strconv.ParseInt(o, 10, 32); err == nil {
-				offset = int32(v)
-			}
+		after, limit, err := parsePagination(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
 		}
 
 		// Get comments by owner and category
-		comments, err := commentRepo.ListByOwnerAndCategory(r.Context(), userID, categoryID, limit, offset)
+		comments, err := commentRepo.ListByOwnerAndCategory(r.Context(), userID, categoryID, after, limit+1)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to fetch comments", err)
 			return
 		}
+		comments, next := paginate(comments, limit, commentCursor)
 
-		responses, err := buildCommentResponses(r.Context(), comments, userID, userRepo, commentReactionRepo, reactionTypeRepo)
+		responses, err := buildCommentResponses(r.Context(), comments, userID, userRepo, commentReactionRepo)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to build comment responses", err)
 			return
 		}
 
-		Success(w, responses)
+		Paginated(w, responses, next)
 	}
 }
 
@@ -282,7 +265,7 @@ func HandleGetUserCommentsByCategory(commentRepo *repository.CommentRepository,
 // @Failure 401 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /comments/{comment_id} [get]
-func HandleGetComment(commentRepo *repository.CommentRepository, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+func HandleGetComment(commentRepo *repository.CommentRepository, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -302,14 +285,14 @@ func HandleGetComment(commentRepo *repository.CommentRepository, userRepo *repos
 			if err == sql.ErrNoRows {
 				NotFound(w, "comment not found")
 			} else {
-				InternalError(w, err.Error())
+				InternalError(w, r, "failed to fetch comment", err)
 			}
 			return
 		}
 
-		response, err := buildCommentResponse(r.Context(), comment, userID, userRepo, commentReactionRepo, reactionTypeRepo)
+		response, err := buildCommentResponse(r.Context(), comment, userID, userRepo, commentReactionRepo)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to build comment response", err)
 			return
 		}
 
@@ -326,8 +309,9 @@ func HandleGetComment(commentRepo *repository.CommentRepository, userRepo *repos
 // @Success 201 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /posts/{post_id}/comments [post]
-func HandleCreateCommentOnPost(commentRepo *repository.CommentRepository, postRepo *repository.PostRepository) http.HandlerFunc {
+func HandleCreateCommentOnPost(commentRepo *repository.CommentRepository, postRepo *repository.PostRepository, notifier *notification.Dispatcher, appMetrics *metrics.Metrics) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -342,12 +326,12 @@ func HandleCreateCommentOnPost(commentRepo *repository.CommentRepository, postRe
 			return
 		}
 
-		_, err = postRepo.GetByID(r.Context(), postID)
+		post, err := postRepo.GetByID(r.Context(), postID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				NotFound(w, "post not found")
 			} else {
-				InternalError(w, err.Error())
+				InternalError(w, r, "failed to fetch post", err)
 			}
 			return
 		}
@@ -373,10 +357,13 @@ func HandleCreateCommentOnPost(commentRepo *repository.CommentRepository, postRe
 
 		_, err = commentRepo.Create(r.Context(), comment)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to create comment", err)
 			return
 		}
 
+		appMetrics.CommentCreated()
+		notifier.CommentOnPost(r.Context(), userID, post)
+
 		Created(w, map[string]string{"message": "Comment created successfully!"})
 	}
 }
@@ -391,8 +378,9 @@ func HandleCreateCommentOnPost(commentRepo *repository.CommentRepository, postRe
 // @Success 201 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /comments/{comment_id}/replies [post]
-func HandleCreateReplyToComment(commentRepo *repository.CommentRepository) http.HandlerFunc {
+func HandleCreateReplyToComment(commentRepo *repository.CommentRepository, notifier *notification.Dispatcher, appMetrics *metrics.Metrics) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -412,7 +400,7 @@ func HandleCreateReplyToComment(commentRepo *repository.CommentRepository) http.
 			if err == sql.ErrNoRows {
 				NotFound(w, "comment not found")
 			} else {
-				InternalError(w, err.Error())
+				InternalError(w, r, "failed to fetch comment", err)
 			}
 			return
 		}
@@ -439,10 +427,13 @@ func HandleCreateReplyToComment(commentRepo *repository.CommentRepository) http.
 
 		_, err = commentRepo.Create(r.Context(), comment)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to create comment", err)
 			return
 		}
 
+		appMetrics.CommentCreated()
+		notifier.ReplyToComment(r.Context(), userID, parentComment)
+
 		Created(w, map[string]string{"message": "Reply created successfully!"})
 	}
 }
@@ -459,7 +450,7 @@ func HandleCreateReplyToComment(commentRepo *repository.CommentRepository) http.
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Router /comments/{comment_id} [put]
-func HandleUpdateComment(commentRepo *repository.CommentRepository) http.HandlerFunc {
+func HandleUpdateComment(commentRepo *repository.CommentRepository, postRepo *repository.PostRepository, moderatorRepo *repository.ModeratorRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -479,14 +470,26 @@ func HandleUpdateComment(commentRepo *repository.CommentRepository) http.Handler
 			if err == sql.ErrNoRows {
 				NotFound(w, "comment not found")
 			} else {
-				InternalError(w, err.Error())
+				InternalError(w, r, "failed to fetch comment", err)
 			}
 			return
 		}
 
 		if comment.OwnerID != userID {
-			Forbidden(w, "you cannot update this comment")
-			return
+			post, err := postRepo.GetByID(r.Context(), comment.PostID)
+			if err != nil {
+				InternalError(w, r, "failed to fetch post", err)
+				return
+			}
+			allowed, err := canModerate(r.Context(), moderatorRepo, post.CategoryID)
+			if err != nil {
+				InternalError(w, r, "failed to check permissions", err)
+				return
+			}
+			if !allowed {
+				Forbidden(w, "you cannot update this comment")
+				return
+			}
 		}
 
 		var req UpdateCommentRequest
@@ -504,7 +507,7 @@ func HandleUpdateComment(commentRepo *repository.CommentRepository) http.Handler
 
 		err = commentRepo.Update(r.Context(), comment)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to update comment", err)
 			return
 		}
 
@@ -514,7 +517,7 @@ func HandleUpdateComment(commentRepo *repository.CommentRepository) http.Handler
 
 // HandleDeleteComment deletes a comment or reply.
 // @Summary Delete a comment
-// @Description Delete a comment and its associated reactions (owner only)
+// @Description Delete a comment and its associated reactions (owner, category moderator or admin)
 // @Tags comments
 // @Security Bearer
 // @Param comment_id path int true "Comment ID"
@@ -522,7 +525,7 @@ func HandleUpdateComment(commentRepo *repository.CommentRepository) http.Handler
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Router /comments/{comment_id} [delete]
-func HandleDeleteComment(commentRepo *repository.CommentRepository) http.HandlerFunc {
+func HandleDeleteComment(commentRepo *repository.CommentRepository, postRepo *repository.PostRepository, moderatorRepo *repository.ModeratorRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -542,19 +545,31 @@ func HandleDeleteComment(commentRepo *repository.CommentRepository) http.Handler
 			if err == sql.ErrNoRows {
 				NotFound(w, "comment not found")
 			} else {
-				InternalError(w, err.Error())
+				InternalError(w, r, "failed to fetch comment", err)
 			}
 			return
 		}
 
 		if comment.OwnerID != userID {
-			Forbidden(w, "you cannot delete this comment")
-			return
+			post, err := postRepo.GetByID(r.Context(), comment.PostID)
+			if err != nil {
+				InternalError(w, r, "failed to fetch post", err)
+				return
+			}
+			allowed, err := canModerate(r.Context(), moderatorRepo, post.CategoryID)
+			if err != nil {
+				InternalError(w, r, "failed to check permissions", err)
+				return
+			}
+			if !allowed {
+				Forbidden(w, "you cannot delete this comment")
+				return
+			}
 		}
 
 		err = commentRepo.Delete(r.Context(), commentID)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to delete comment", err)
 			return
 		}
 
@@ -568,11 +583,12 @@ func HandleDeleteComment(commentRepo *repository.CommentRepository) http.Handler
 // @Security Bearer
 // @Param comment_id path int true "Comment ID"
 // @Param request body ReactCommentRequest true "Reaction type"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} ReactionSummaryResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /comments/{comment_id}/react [post]
-func HandleReactToComment(commentRepo *repository.CommentRepository, commentReactionRepo *repository.CommentReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+func HandleReactToComment(commentRepo *repository.CommentRepository, commentReactionRepo *repository.CommentReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository, notifier *notification.Dispatcher, appMetrics *metrics.Metrics) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -587,12 +603,12 @@ func HandleReactToComment(commentRepo *repository.CommentRepository, commentReac
 			return
 		}
 
-		_, err = commentRepo.GetByID(r.Context(), commentID)
+		comment, err := commentRepo.GetByID(r.Context(), commentID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				NotFound(w, "comment not found")
 			} else {
-				InternalError(w, err.Error())
+				InternalError(w, r, "failed to fetch comment", err)
 			}
 			return
 		}
@@ -608,16 +624,21 @@ func HandleReactToComment(commentRepo *repository.CommentRepository, commentReac
 			return
 		}
 
-		_, err = reactionTypeRepo.GetByID(r.Context(), req.ReactionTypeID)
+		reactionType, err := reactionTypeRepo.GetByID(r.Context(), req.ReactionTypeID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				NotFound(w, "reaction type not found")
 			} else {
-				InternalError(w, err.Error())
+				InternalError(w, r, "failed to fetch reaction type", err)
 			}
 			return
 		}
 
+		if reactionType.RetiredAt != nil {
+			ValidationError(w, "reaction type is retired")
+			return
+		}
+
 		reaction := &entity.CommentReaction{
 			CommentID:      commentID,
 			OwnerID:        userID,
@@ -626,67 +647,135 @@ func HandleReactToComment(commentRepo *repository.CommentRepository, commentReac
 
 		_, err = commentReactionRepo.Upsert(r.Context(), reaction)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to record reaction", err)
 			return
 		}
+		appMetrics.ReactionCreated("comment")
+
+		notifier.ReactToComment(r.Context(), userID, comment)
 
-		Success(w, map[string]string{"message": "Reaction recorded"})
+		summary, err := buildCommentReactionSummary(r.Context(), commentReactionRepo, commentID, userID)
+		if err != nil {
+			InternalError(w, r, "failed to build comment reaction summary", err)
+			return
+		}
+		Success(w, summary)
+	}
+}
+
+// @Summary Remove reaction from a comment
+// @Description Take back the authenticated user's reaction to a specific comment or reply
+// @Tags comments
+// @Security Bearer
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {object} ReactionSummaryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /comments/{comment_id}/react [delete]
+func HandleRemoveCommentReaction(commentReactionRepo *repository.CommentReactionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			Unauthorized(w, "unauthorized")
+			return
+		}
+
+		commentIDStr := chi.URLParam(r, "comment_id")
+		commentID, err := strconv.ParseInt(commentIDStr, 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid comment_id")
+			return
+		}
+
+		if err := commentReactionRepo.DeleteByOwnerAndComment(r.Context(), userID, commentID); err != nil {
+			if err == sql.ErrNoRows {
+				NotFound(w, "reaction not found")
+			} else {
+				InternalError(w, r, "failed to remove comment reaction", err)
+			}
+			return
+		}
+
+		summary, err := buildCommentReactionSummary(r.Context(), commentReactionRepo, commentID, userID)
+		if err != nil {
+			InternalError(w, r, "failed to build comment reaction summary", err)
+			return
+		}
+		Success(w, summary)
 	}
 }
 
 // buildCommentResponse builds a comment response from a comment entity with owner and reaction data
-func buildCommentResponse(ctx context.Context, comment *entity.Comment, userID int64, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) (*CommentResponse, error) {
-	owner, err := userRepo.GetByID(ctx, comment.OwnerID)
+func buildCommentResponse(ctx context.Context, comment *entity.Comment, userID int64, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository) (*CommentResponse, error) {
+	responses, err := buildCommentResponses(ctx, []*entity.Comment{comment}, userID, userRepo, commentReactionRepo)
 	if err != nil {
 		return nil, err
 	}
+	return responses[0], nil
+}
+
+// buildCommentResponses builds comment responses with owner and reaction data
+// Owners, reaction counts and the user's reactions are loaded with one query each for the whole page
+func buildCommentResponses(ctx context.Context, comments []*entity.Comment, userID int64, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository) ([]*CommentResponse, error) {
+	if len(comments) == 0 {
+		return []*CommentResponse{}, nil
+	}
+
+	commentIDs := make([]int64, len(comments))
+	ownerIDs := make([]int64, 0, len(comments))
+	seen := make(map[int64]bool, len(comments))
+	for i, comment := range comments {
+		commentIDs[i] = comment.ID
+		if !seen[comment.OwnerID] {
+			seen[comment.OwnerID] = true
+			ownerIDs = append(ownerIDs, comment.OwnerID)
+		}
+	}
 
-	totalReaction, err := commentReactionRepo.Count(ctx, comment.ID)
+	owners, err := userRepo.GetByIDs(ctx, ownerIDs)
 	if err != nil {
 		return nil, err
 	}
-
-	var userReaction *ReactionInfo
-	reaction, err := commentReactionRepo.GetByOwnerAndComment(ctx, userID, comment.ID)
-	if err != nil && err != sql.ErrNoRows {
+	counts, err := commentReactionRepo.CountTypesByComments(ctx, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+	userReactions, err := commentReactionRepo.GetTypesByOwnerAndComments(ctx, userID, commentIDs)
+	if err != nil {
 		return nil, err
 	}
-	if reaction != nil {
-		reactionType, err := reactionTypeRepo.GetByID(ctx, reaction.ReactionTypeID)
-		if err != nil && err != sql.ErrNoRows {
-			return nil, err
+
+	responses := make([]*CommentResponse, len(comments))
+	for i, comment := range comments {
+		owner, ok := owners[comment.OwnerID]
+		if !ok {
+			return nil, sql.ErrNoRows
 		}
-		if reactionType != nil {
+
+		var userReaction *ReactionInfo
+		if rt, ok := userReactions[comment.ID]; ok {
 			userReaction = &ReactionInfo{
-				ReactionTypeID: reactionType.ID,
-				Name:           reactionType.Name,
+				ReactionTypeID: rt.ID,
+				Name:           rt.Name,
+				Image:          rt.Image,
 			}
 		}
-	}
 
-	return &CommentResponse{
-		CommentID:            comment.ID,
-		CommentOwnerUsername: owner.Username,
-		ProfilePicture:       owner.ProfilePicture,
-		Text:                 comment.Text,
-		Image:                comment.Image,
-		CreatedAt:            comment.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:            comment.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-		IsEdited:             comment.Status,
-		TotalReaction:        totalReaction,
-		UserReaction:         userReaction,
-	}, nil
-}
-
-// buildCommentResponses builds multiple comment responses by calling buildCommentResponse for each comment
-func buildCommentResponses(ctx context.Context, comments []*entity.Comment, userID int64, userRepo *repository.UserRepository, commentReactionRepo *repository.CommentReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) ([]*CommentResponse, error) {
-	var responses []*CommentResponse
-	for _, comment := range comments {
-		response, err := buildCommentResponse(ctx, comment, userID, userRepo, commentReactionRepo, reactionTypeRepo)
-		if err != nil {
-			return nil, err
+		reactions, total := toReactionCounts(counts[comment.ID])
+		responses[i] = &CommentResponse{
+			CommentID:            comment.ID,
+			CommentOwnerUsername: owner.Username,
+			ProfilePicture:       owner.ProfilePicture,
+			Text:                 comment.Text,
+			Image:                comment.Image,
+			CreatedAt:            comment.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			UpdatedAt:            comment.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			IsEdited:             comment.Status,
+			TotalReaction:        total,
+			Reactions:            reactions,
+			UserReaction:         userReaction,
 		}
-		responses = append(responses, response)
 	}
 	return responses, nil
 }
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// countingConnector opens connections to a fake database counting the queries it receives
+// Every ID of an ANY($n) argument gets a row, so the response builders see owners and reactions for each item
+// A post looked up by ID exists and has two comments, queries containing fail return an error
+type countingConnector struct {
+	queries atomic.Int64
+	fail    string
+}
+
+func (c *countingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &countingConn{connector: c}, nil
+}
+
+func (c *countingConnector) Driver() driver.Driver {
+	return nil
+}
+
+type countingConn struct {
+	connector *countingConnector
+}
+
+func (c *countingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepared statements are not supported")
+}
+
+func (c *countingConn) Close() error {
+	return nil
+}
+
+func (c *countingConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+func (c *countingConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.connector.queries.Add(1)
+	if c.connector.fail != "" && strings.Contains(query, c.connector.fail) {
+		return nil, fmt.Errorf("query failed")
+	}
+
+	now := time.Now()
+	switch {
+	case strings.Contains(query, "FROM posts"):
+		return &fakeRows{
+			columns: []string{"post_id", "owner_id", "category_id", "headline", "text", "image", "created_at", "updated_at", "status"},
+			values:  [][]driver.Value{{args[0].Value, int64(1), int64(1), "headline", nil, nil, now, now, false}},
+		}, nil
+	case strings.Contains(query, "FROM comments"):
+		columns := []string{"comment_id", "post_id", "owner_id", "parent_comment_id", "text", "image", "created_at", "updated_at", "status"}
+		return &fakeRows{columns: columns, values: [][]driver.Value{
+			{int64(1), args[0].Value, int64(2), nil, "first", nil, now, now, false},
+			{int64(2), args[0].Value, int64(3), nil, "second", nil, now, now, false},
+		}}, nil
+	}
+
+	// The ID list is the last argument, encoded by pq.Array as {1,2,3}
+	ids, err := parseIDArray(args[len(args)-1].Value)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := &fakeRows{}
+	switch {
+	case strings.Contains(query, "FROM users"):
+		rows.columns = []string{"user_id", "username", "email", "password", "profile_picture", "role", "email_verified_at", "created_at"}
+		for _, id := range ids {
+			rows.values = append(rows.values, []driver.Value{id, "user" + strconv.FormatInt(id, 10), "user@example.com", "hash", nil, "user", now, now})
+		}
+	case strings.Contains(query, "COUNT(*)"):
+		rows.columns = []string{"reaction_type_id", "name", "image", "id", "count"}
+		for _, id := range ids {
+			rows.values = append(rows.values, []driver.Value{int64(1), "Like", nil, id, int64(3)})
+		}
+	default:
+		rows.columns = []string{"reaction_type_id", "name", "image", "id"}
+		for _, id := range ids {
+			rows.values = append(rows.values, []driver.Value{int64(1), "Like", nil, id})
+		}
+	}
+	return rows, nil
+}
+
+// parseIDArray decodes a Postgres bigint array literal
+func parseIDArray(v driver.Value) ([]int64, error) {
+	s, ok := v.(string)
+	if !ok {
+		if b, isBytes := v.([]byte); isBytes {
+			s = string(b)
+		} else {
+			return nil, fmt.Errorf("unexpected ID list argument %T", v)
+		}
+	}
+	s = strings.Trim(s, "{}")
+	if s == "" {
+		return nil, nil
+	}
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status values of the readiness report
+const (
+	readyOK       = "ok"
+	readyFailed   = "failed"
+	readyTimeout  = "timeout"
+	readyDraining = "draining"
+)
+
+// ReadinessCheck probes one dependency the server needs to serve traffic
+// Check is cancelled once Timeout elapses so a hanging dependency cannot stall the probe
+type ReadinessCheck struct {
+	Name    string
+	Timeout time.Duration
+	Check   func(ctx context.Context) error
+}
+
+// ReadinessReport is the payload of the readiness endpoint
+type ReadinessReport struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// CheckResult is the outcome of one readiness check
+// Failure details are only logged, the report names the failing dependency
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// Lifecycle tracks whether the server is draining before shutdown
+type Lifecycle struct {
+	draining atomic.Bool
+}
+
+// NewLifecycle creates a new Lifecycle for a server accepting traffic
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// StartDraining marks the server as shutting down
+func (l *Lifecycle) StartDraining() {
+	l.draining.Store(true)
+}
+
+// Draining reports whether the server is shutting down
+func (l *Lifecycle) Draining() bool {
+	return l.draining.Load()
+}
+
+// HandleHealth answers load balancer health checks
+// It turns to 503 "draining" once shutdown started so no new traffic is routed here
+func HandleHealth(lifecycle *Lifecycle) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if lifecycle.Draining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("draining"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}
+}
+
+// @Summary Liveness probe
+// @Description Report that the process is running, it stays 200 while draining so the instance is not restarted
+// @Tags health
+// @Success 200 {string} string "ok"
+// @Router /livez [get]
+func HandleLivez() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}
+}
+
+// @Summary Readiness probe
+// @Description Check the database, the schema version and the storage backend, 503 until all of them pass
+// @Tags health
+// @Success 200 {object} ReadinessReport
+// @Failure 503 {object} ReadinessReport
+// @Router /readyz [get]
+func HandleReadyz(lifecycle *Lifecycle, checks []ReadinessCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+
+		if lifecycle.Draining() {
+			JSON(w, http.StatusServiceUnavailable, ReadinessReport{Status: readyDraining, Checks: []CheckResult{}})
+			return
+		}
+
+		// Checks run concurrently so the probe takes as long as the slowest dependency
+		results := make([]CheckResult, len(checks))
+		var wg sync.WaitGroup
+		for i, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = runReadinessCheck(r, check)
+			}()
+		}
+		wg.Wait()
+
+		report := ReadinessReport{Status: readyOK, Checks: results}
+		statusCode := http.StatusOK
+		for _, result := range results {
+			if result.Status != readyOK {
+				report.Status = readyFailed
+				statusCode = http.StatusServiceUnavailable
+			}
+		}
+		JSON(w, statusCode, report)
+	}
+}
+
+// runReadinessCheck runs check within its timeout and logs why it failed
+func runReadinessCheck(r *http.Request, check ReadinessCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(r.Context(), check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	result := CheckResult{
+		Name:      check.Name,
+		Status:    readyOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = readyFailed
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.Status = readyTimeout
+		}
+		Logger(r.Context()).Warn("readiness check failed", "check", check.Name, "status", result.Status, "error", err)
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIDHeader carries the request ID from clients and proxies and back in responses
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID limits incoming request IDs to characters that are safe to log and echo
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+const requestInfoKey contextKey = "requestInfo"
+
+// requestInfo describes the request being served for its log entries
+// AuthMiddleware fills in the user ID once it authenticated the request
+type requestInfo struct {
+	id     string
+	userID int64
+	logger *slog.Logger
+}
+
+// RequestLogger assigns every request an ID and writes one structured log entry per request
+// The ID is taken from the X-Request-ID header when the client or a proxy sent a valid one
+func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(requestIDHeader)
+			if !validRequestID.MatchString(id) {
+				generated, err := randomToken(8)
+				if err != nil {
+					generated = "unknown"
+				}
+				id = generated
+			}
+			w.Header().Set(requestIDHeader, id)
+
+			// The trace ID lets the log lines of a request be found from its trace
+			reqLogger := logger.With("request_id", id)
+			if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+				reqLogger = reqLogger.With("trace_id", sc.TraceID().String())
+			}
+			info := &requestInfo{id: id, logger: reqLogger}
+			ctx := context.WithValue(r.Context(), requestInfoKey, info)
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			attrs := []any{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"route", chi.RouteContext(ctx).RoutePattern(),
+				"status", status,
+				"bytes", ww.BytesWritten(),
+				"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+				"remote_ip", clientIP(r),
+			}
+			if info.userID != 0 {
+				attrs = append(attrs, "user_id", info.userID)
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			info.logger.Log(ctx, level, "request", attrs...)
+		})
+	}
+}
+
+// Recoverer turns panics in handlers into logged 500 responses
+// Must be mounted after RequestLogger so the panic is logged with the request ID
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// The server uses ErrAbortHandler to abort responses, let it through
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			InternalError(w, r, "panic serving request", fmt.Errorf("%v\n%s", rec, debug.Stack()))
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetRequestID retrieves the ID assigned to the request by RequestLogger
+func GetRequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// Logger returns the logger of the request, tagged with its request ID
+// The default logger is returned outside of RequestLogger
+func Logger(ctx context.Context) *slog.Logger {
+	if info, ok := ctx.Value(requestInfoKey).(*requestInfo); ok {
+		return info.logger
+	}
+	return slog.Default()
+}
+
+// setLogUserID adds the authenticated user to the log entry of the request
+func setLogUserID(ctx context.Context, userID int64) {
+	if info, ok := ctx.Value(requestInfoKey).(*requestInfo); ok {
+		info.userID = userID
+	}
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"my-chi-app/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// unmatchedRoute labels requests no route matched, so unknown paths cannot create new series
+const unmatchedRoute = "unmatched"
+
+// Instrument records the count and latency of every request by chi route pattern and status
+func Instrument(appMetrics *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if appMetrics == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			route := chi.RouteContext(r.Context()).RoutePattern()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			appMetrics.ObserveRequest(r.Method, route, status, time.Since(start))
+		})
+	}
+}
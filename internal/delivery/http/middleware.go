@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -18,13 +19,15 @@ import (
 type contextKey string
 
 const (
-	userIDKey contextKey = "userID"
+	userIDKey        contextKey = "userID"
+	userRoleKey      contextKey = "userRole"
+	emailVerifiedKey contextKey = "emailVerified"
 )
 
-// AuthMiddleware validates bearer tokens and injects user ID into request context
+// AuthMiddleware validates bearer tokens and injects user ID, global role and verification status into request context
 // Check both the validity and its presence in the token repository
 // Expect Authorization: Bearer <token>
-func AuthMiddleware(tokenRepo *repository.TokenRepository, jwtSecret string) func(http.Handler) http.Handler {
+func AuthMiddleware(tokenRepo *repository.TokenRepository, userRepo *repository.UserRepository, jwtSecret string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract token from Authorization header
@@ -72,7 +75,13 @@ func AuthMiddleware(tokenRepo *repository.TokenRepository, jwtSecret string) fun
 					http.Error(w, "invalid token", http.StatusUnauthorized)
 					return
 				}
-				http.Error(w, "internal error", http.StatusInternalServerError)
+				InternalError(w, r, "failed to fetch token", err)
+				return
+			}
+
+			// Rotated tokens were replaced by a refresh and must not be used anymore
+			if t.RotatedAt != nil {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
 				return
 			}
 
@@ -81,7 +90,22 @@ func AuthMiddleware(tokenRepo *repository.TokenRepository, jwtSecret string) fun
 				return
 			}
 
+			_ = tokenRepo.TouchLastUsed(ctx, t.ID)
+
+			user, err := userRepo.GetByID(ctx, userID)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					http.Error(w, "invalid token", http.StatusUnauthorized)
+					return
+				}
+				InternalError(w, r, "failed to fetch user", err)
+				return
+			}
+
+			setLogUserID(ctx, userID)
 			ctx = context.WithValue(ctx, userIDKey, userID)
+			ctx = context.WithValue(ctx, userRoleKey, user.Role)
+			ctx = context.WithValue(ctx, emailVerifiedKey, user.EmailVerifiedAt != nil)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -93,12 +117,77 @@ func GetUserID(ctx context.Context) (int64, bool) {
 	return userID, ok
 }
 
+// GetUserRole retrieves the global role of the authenticated user from the request
+func GetUserRole(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(userRoleKey).(string)
+	return role, ok
+}
+
+// IsAdmin reports whether the authenticated user is a global admin
+func IsAdmin(ctx context.Context) bool {
+	role, ok := GetUserRole(ctx)
+	return ok && role == entity.RoleAdmin
+}
+
+// RequireRole only lets through users holding one of the given global roles
+// Must be mounted after AuthMiddleware
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := GetUserRole(r.Context())
+			if !ok {
+				Unauthorized(w, "user not authenticated")
+				return
+			}
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			Forbidden(w, "insufficient permissions")
+		})
+	}
+}
+
+// IsEmailVerified reports whether the authenticated user has verified their email
+func IsEmailVerified(ctx context.Context) bool {
+	verified, ok := ctx.Value(emailVerifiedKey).(bool)
+	return ok && verified
+}
+
+// RequireVerifiedEmail only lets through users who verified their email
+// Must be mounted after AuthMiddleware
+func RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsEmailVerified(r.Context()) {
+			Forbidden(w, "verify your email address to continue")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// canModerate reports whether the authenticated user may moderate content in a category
+// Global admins moderate every category
+func canModerate(ctx context.Context, moderatorRepo *repository.ModeratorRepository, categoryID int64) (bool, error) {
+	if IsAdmin(ctx) {
+		return true, nil
+	}
+	userID, ok := GetUserID(ctx)
+	if !ok {
+		return false, nil
+	}
+	return moderatorRepo.IsModerator(ctx, userID, categoryID)
+}
+
 // CORS configure and add CORS headers for cross-origin requests
 func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, traceparent, tracestate")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
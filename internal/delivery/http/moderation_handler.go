@@ -0,0 +1,246 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// UpdateRoleRequest is the payload for changing a user's global role
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// AddModeratorRequest is the payload for granting moderation rights in a category
+type AddModeratorRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+// ModeratorResponse is the payload response when returning moderator information
+type ModeratorResponse struct {
+	UserID     int64  `json:"user_id"`
+	Username   string `json:"username"`
+	CategoryID int64  `json:"category_id"`
+	Since      string `json:"since"`
+}
+
+// @Summary Update a user's role
+// @Description Change the global role of a user (admin only)
+// @Tags moderation
+// @Security Bearer
+// @Param user_id path int true "User ID"
+// @Param request body UpdateRoleRequest true "New role"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{user_id}/role [put]
+func HandleUpdateUserRole(userRepo *repository.UserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIDStr := chi.URLParam(r, "user_id")
+		userID, err := strconv.ParseInt(userIDStr, 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid user_id")
+			return
+		}
+
+		var req UpdateRoleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "invalid request body")
+			return
+		}
+
+		if req.Role != entity.RoleUser && req.Role != entity.RoleAdmin {
+			ValidationError(w, "role must be one of: user, admin")
+			return
+		}
+
+		if err := userRepo.UpdateRole(r.Context(), userID, req.Role); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "user not found")
+				return
+			}
+			InternalError(w, r, "failed to update role", err)
+			return
+		}
+
+		Success(w, MessageResponse{
+			Message: "Role updated successfully!",
+		})
+	}
+}
+
+// @Summary Get category moderators
+// @Description Retrieve the list of moderators of a category
+// @Tags moderation
+// @Security Bearer
+// @Param category_id path int true "Category ID"
+// @Success 200 {array} ModeratorResponse
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /categories/{category_id}/moderators [get]
+func HandleGetCategoryModerators(categoryRepo *repository.CategoryRepository, moderatorRepo *repository.ModeratorRepository, userRepo *repository.UserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		categoryIDStr := chi.URLParam(r, "category_id")
+		categoryID, err := strconv.ParseInt(categoryIDStr, 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid category_id")
+			return
+		}
+
+		ctx := r.Context()
+
+		if _, err := categoryRepo.GetByID(ctx, categoryID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "category not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch category", err)
+			return
+		}
+
+		moderators, err := moderatorRepo.ListByCategory(ctx, categoryID)
+		if err != nil {
+			InternalError(w, r, "failed to fetch moderators", err)
+			return
+		}
+
+		response := make([]ModeratorResponse, 0, len(moderators))
+		for _, m := range moderators {
+			user, err := userRepo.GetByID(ctx, m.UserID)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					continue
+				}
+				InternalError(w, r, "failed to fetch user", err)
+				return
+			}
+			response = append(response, ModeratorResponse{
+				UserID:     user.ID,
+				Username:   user.Username,
+				CategoryID: m.CategoryID,
+				Since:      m.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			})
+		}
+
+		Success(w, response)
+	}
+}
+
+// @Summary Add a category moderator
+// @Description Grant a user moderation rights in a category (admin only)
+// @Tags moderation
+// @Security Bearer
+// @Param category_id path int true "Category ID"
+// @Param request body AddModeratorRequest true "User to promote"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /categories/{category_id}/moderators [post]
+func HandleAddCategoryModerator(categoryRepo *repository.CategoryRepository, moderatorRepo *repository.ModeratorRepository, userRepo *repository.UserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		categoryIDStr := chi.URLParam(r, "category_id")
+		categoryID, err := strconv.ParseInt(categoryIDStr, 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid category_id")
+			return
+		}
+
+		var req AddModeratorRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "invalid request body")
+			return
+		}
+
+		if req.UserID <= 0 {
+			ValidationError(w, "user_id is required")
+			return
+		}
+
+		ctx := r.Context()
+
+		if _, err := categoryRepo.GetByID(ctx, categoryID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "category not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch category", err)
+			return
+		}
+
+		if _, err := userRepo.GetByID(ctx, req.UserID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "user not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch user", err)
+			return
+		}
+
+		moderator := &entity.Moderator{
+			CategoryID: categoryID,
+			UserID:     req.UserID,
+		}
+		if _, err := moderatorRepo.Create(ctx, moderator); err != nil {
+			InternalError(w, r, "failed to add moderator", err)
+			return
+		}
+
+		Success(w, MessageResponse{
+			Message: "Moderator added successfully!",
+		})
+	}
+}
+
+// @Summary Remove a category moderator
+// @Description Revoke a user's moderation rights in a category (admin only)
+// @Tags moderation
+// @Security Bearer
+// @Param category_id path int true "Category ID"
+// @Param user_id path int true "User ID"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /categories/{category_id}/moderators/{user_id} [delete]
+func HandleRemoveCategoryModerator(moderatorRepo *repository.ModeratorRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		categoryIDStr := chi.URLParam(r, "category_id")
+		categoryID, err := strconv.ParseInt(categoryIDStr, 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid category_id")
+			return
+		}
+
+		userIDStr := chi.URLParam(r, "user_id")
+		userID, err := strconv.ParseInt(userIDStr, 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid user_id")
+			return
+		}
+
+		if err := moderatorRepo.DeleteByUserAndCategory(r.Context(), userID, categoryID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "moderator not found")
+				return
+			}
+			InternalError(w, r, "failed to remove moderator", err)
+			return
+		}
+
+		Success(w, MessageResponse{
+			Message: "Moderator removed successfully!",
+		})
+	}
+}
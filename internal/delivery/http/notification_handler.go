@@ -2,14 +2,25 @@ package http
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+	"my-chi-app/internal/notification"
 )
 
+// streamHeartbeatInterval keeps idle notification streams alive through proxies
+const streamHeartbeatInterval = 25 * time.Second
+
+// streamReplayBatch is the number of missed notifications fetched per query on reconnect
+const streamReplayBatch = 100
+
 // NotificationResponse is the payload response when returning notification information
 type NotificationResponse struct {
 	NotificationID    int64  `json:"notification_id"`
@@ -25,9 +36,10 @@ type NotificationResponse struct {
 // @Description Fetch paginated notifications of the authenticated user
 // @Tags notifications
 // @Security Bearer
-// @Param limit query int false "Limit" default(1000)
-// @Param offset query int false "Offset" default(0)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {array} NotificationResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /notifications [get]
 func HandleGetAllUserNotifications(notificationRepo *repository.NotificationRepository) http.HandlerFunc {
@@ -38,46 +50,25 @@ func HandleGetAllUserNotifications(notificationRepo *repository.NotificationRepo
 			return
 		}
 
-		// Pagination
-		limit, offset := int32(1000), int32(0)
-		if l := r.URL.Query().Get("limit"); l != "" {
-			if v, err := strconv.ParseInt(l, 10, 32); err == nil {
-				limit = int32(v)
-			}
-		}
-		if o := r.URL.Query().Get("offset"); o != "" {
-			if v, err := strconv.ParseInt(o, 10, 32); err == nil {
-				offset = int32(v)
-			}
+		after, limit, err := parsePagination(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
 		}
 
-		list, err := notificationRepo.ListByOwner(r.Context(), userID, limit, offset)
+		list, err := notificationRepo.ListByOwner(r.Context(), userID, after, limit+1)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to fetch notifications", err)
 			return
 		}
+		list, next := paginate(list, limit, notificationCursor)
 
 		resp := make([]NotificationResponse, 0, len(list))
 		for _, n := range list {
-			var postID, commentID *int64
-			switch n.ComponentType {
-			case "post":
-				postID = &n.ComponentID
-			case "comment":
-				commentID = &n.ComponentID
-			}
-			resp = append(resp, NotificationResponse{
-				NotificationID:    n.ID,
-				ActorID:           n.ActorID,
-				ComponentInvolved: n.ComponentType,
-				PostID:            postID,
-				CommentID:         commentID,
-				NotificationType:  n.NotificationType,
-				Status:            n.Status,
-			})
+			resp = append(resp, toNotificationResponse(n))
 		}
 
-		Success(w, resp)
+		Paginated(w, resp, next)
 	}
 }
 
@@ -111,7 +102,7 @@ func HandleMarkNotificationAsRead(notificationRepo *repository.NotificationRepos
 			if err == sql.ErrNoRows {
 				NotFound(w, "notification not found")
 			} else {
-				InternalError(w, err.Error())
+				InternalError(w, r, "failed to fetch notification", err)
 			}
 			return
 		}
@@ -125,7 +116,7 @@ func HandleMarkNotificationAsRead(notificationRepo *repository.NotificationRepos
 				NotFound(w, "notification not found")
 				return
 			}
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to mark notification as read", err)
 			return
 		}
 
@@ -163,7 +154,7 @@ func HandleMarkNotificationAsUnread(notificationRepo *repository.NotificationRep
 			if err == sql.ErrNoRows {
 				NotFound(w, "notification not found")
 			} else {
-				InternalError(w, err.Error())
+				InternalError(w, r, "failed to fetch notification", err)
 			}
 			return
 		}
@@ -177,7 +168,7 @@ func HandleMarkNotificationAsUnread(notificationRepo *repository.NotificationRep
 				NotFound(w, "notification not found")
 				return
 			}
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to mark notification as unread", err)
 			return
 		}
 
@@ -189,9 +180,10 @@ func HandleMarkNotificationAsUnread(notificationRepo *repository.NotificationRep
 // @Description Fetch paginated all notifications that are read of the authenticated user
 // @Tags notifications
 // @Security Bearer
-// @Param limit query int false "Limit" default(1000)
-// @Param offset query int false "Offset" default(0)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {array} NotificationResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /notifications/read [get]
 func HandleGetAllReadNotifications(notificationRepo *repository.NotificationRepository) http.HandlerFunc {
@@ -202,45 +194,25 @@ func HandleGetAllReadNotifications(notificationRepo *repository.NotificationRepo
 			return
 		}
 
-		limit, offset := int32(1000), int32(0)
-		if l := r.URL.Query().Get("limit"); l != "" {
-			if v, err := strconv.ParseInt(l, 10, 32); err == nil {
-				limit = int32(v)
-			}
-		}
-		if o := r.URL.Query().Get("offset"); o != "" {
-			if v, err := strconv.ParseInt(o, 10, 32); err == nil {
-				offset = int32(v)
-			}
+		after, limit, err := parsePagination(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
 		}
 
-		list, err := notificationRepo.ListByOwnerAndStatus(r.Context(), userID, true, limit, offset)
+		list, err := notificationRepo.ListByOwnerAndStatus(r.Context(), userID, true, after, limit+1)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to fetch notifications", err)
 			return
 		}
+		list, next := paginate(list, limit, notificationCursor)
 
 		resp := make([]NotificationResponse, 0, len(list))
 		for _, n := range list {
-			var postID, commentID *int64
-			switch n.ComponentType {
-			case "post":
-				postID = &n.ComponentID
-			case "comment":
-				commentID = &n.ComponentID
-			}
-			resp = append(resp, NotificationResponse{
-				NotificationID:    n.ID,
-				ActorID:           n.ActorID,
-				ComponentInvolved: n.ComponentType,
-				PostID:            postID,
-				CommentID:         commentID,
-				NotificationType:  n.NotificationType,
-				Status:            n.Status,
-			})
+			resp = append(resp, toNotificationResponse(n))
 		}
 
-		Success(w, resp)
+		Paginated(w, resp, next)
 	}
 }
 
@@ -248,9 +220,10 @@ func HandleGetAllReadNotifications(notificationRepo *repository.NotificationRepo
 // @Description Fetch paginated all notifications that are unread of the authenticated user
 // @Tags notifications
 // @Security Bearer
-// @Param limit query int false "Limit" default(1000)
-// @Param offset query int false "Offset" default(0)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {array} NotificationResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /notifications/unread [get]
 func HandleGetAllUnreadNotifications(notificationRepo *repository.NotificationRepository) http.HandlerFunc {
@@ -261,44 +234,164 @@ func HandleGetAllUnreadNotifications(notificationRepo *repository.NotificationRe
 			return
 		}
 
-		limit, offset := int32(1000), int32(0)
-		if l := r.URL.Query().Get("limit"); l != "" {
-			if v, err := strconv.ParseInt(l, 10, 32); err == nil {
-				limit = int32(v)
-			}
-		}
-		if o := r.URL.Query().Get("offset"); o != "" {
-			if v, err := strconv.ParseInt(o, 10, 32); err == nil {
-				offset = int32(v)
-			}
+		after, limit, err := parsePagination(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
 		}
 
-		list, err := notificationRepo.ListByOwnerAndStatus(r.Context(), userID, false, limit, offset)
+		list, err := notificationRepo.ListByOwnerAndStatus(r.Context(), userID, false, after, limit+1)
 		if err != nil {
-			InternalError(w, err.Error())
+			InternalError(w, r, "failed to fetch notifications", err)
 			return
 		}
+		list, next := paginate(list, limit, notificationCursor)
 
 		resp := make([]NotificationResponse, 0, len(list))
 		for _, n := range list {
-			var postID, commentID *int64
-			switch n.ComponentType {
-			case "post":
-				postID = &n.ComponentID
-			case "comment":
-				commentID = &n.ComponentID
+			resp = append(resp, toNotificationResponse(n))
+		}
+
+		Paginated(w, resp, next)
+	}
+}
+
+// @Summary Stream notifications
+// @Description Push new notifications of the authenticated user as Server-Sent Events.
+// @Description Reconnect with the Last-Event-ID header or last_event_id query to replay missed notifications.
+// @Description Notification IDs are not committed in order, a notification committed after a newer one was sent
+// @Description is pushed live but not replayed after that ID, the list endpoints always include it.
+// @Tags notifications
+// @Security Bearer
+// @Produce text/event-stream
+// @Param last_event_id query int false "Last received notification ID"
+// @Success 200 {object} NotificationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /notifications/stream [get]
+func HandleNotificationStream(notificationRepo *repository.NotificationRepository, broker notification.Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			Unauthorized(w, "unauthorized")
+			return
+		}
+
+		// Cursor of the last notification the client received
+		lastID := int64(0)
+		cursor := r.Header.Get("Last-Event-ID")
+		if cursor == "" {
+			cursor = r.URL.Query().Get("last_event_id")
+		}
+		if cursor != "" {
+			v, err := strconv.ParseInt(cursor, 10, 64)
+			if err != nil || v < 0 {
+				BadRequest(w, "invalid last_event_id")
+				return
+			}
+			lastID = v
+		}
+
+		rc := http.NewResponseController(w)
+		// Streams outlive the server write timeout
+		_ = rc.SetWriteDeadline(time.Time{})
+
+		// Subscribe before replaying so nothing created in between is missed
+		events, unsubscribe := broker.Subscribe(userID)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		ctx := r.Context()
+
+		// IDs sent during replay, the broker may deliver them again as it was subscribed first
+		// Only these are skipped, IDs are not committed in order so a live event below the newest replayed one is still new
+		replayed := make(map[int64]struct{})
+		if lastID > 0 {
+			after := lastID
+			for {
+				missed, err := notificationRepo.ListByOwnerAfter(ctx, userID, after, streamReplayBatch)
+				if err != nil {
+					return
+				}
+				for _, n := range missed {
+					if err := writeNotificationEvent(w, rc, n); err != nil {
+						return
+					}
+					replayed[n.ID] = struct{}{}
+					after = n.ID
+				}
+				if len(missed) < streamReplayBatch {
+					break
+				}
+			}
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n, ok := <-events:
+				if !ok {
+					return
+				}
+				if _, ok := replayed[n.ID]; ok {
+					delete(replayed, n.ID)
+					continue
+				}
+				if err := writeNotificationEvent(w, rc, n); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
 			}
-			resp = append(resp, NotificationResponse{
-				NotificationID:    n.ID,
-				ActorID:           n.ActorID,
-				ComponentInvolved: n.ComponentType,
-				PostID:            postID,
-				CommentID:         commentID,
-				NotificationType:  n.NotificationType,
-				Status:            n.Status,
-			})
 		}
+	}
+}
 
-		Success(w, resp)
+// toNotificationResponse converts a notification entity to its response shape
+func toNotificationResponse(n *entity.Notification) NotificationResponse {
+	var postID, commentID *int64
+	switch n.ComponentType {
+	case entity.NotificationComponentPost:
+		postID = &n.ComponentID
+	case entity.NotificationComponentComment:
+		commentID = &n.ComponentID
+	}
+	return NotificationResponse{
+		NotificationID:    n.ID,
+		ActorID:           n.ActorID,
+		ComponentInvolved: n.ComponentType,
+		PostID:            postID,
+		CommentID:         commentID,
+		NotificationType:  n.NotificationType,
+		Status:            n.Status,
+	}
+}
+
+// writeNotificationEvent writes a notification as a single Server-Sent Event and flushes it
+func writeNotificationEvent(w http.ResponseWriter, rc *http.ResponseController, n *entity.Notification) error {
+	data, err := json.Marshal(toNotificationResponse(n))
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: notification\ndata: %s\n\n", n.ID, data); err != nil {
+		return err
 	}
+	return rc.Flush()
 }
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+)
+
+// Page size limits shared by every list endpoint
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// errInvalidCursor is returned when the cursor query parameter cannot be decoded
+var errInvalidCursor = errors.New("invalid cursor")
+
+// defaultTopWindow is the time window of the top order when none is requested
+const defaultTopWindow = "week"
+
+// topWindows maps the window query parameter of the top order to its length, zero meaning all time
+var topWindows = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+	"all":   0,
+}
+
+// postPageCursor is the encoded form of a repository.PostCursor, tied to the order it was issued for
+// Since is the start of the top window of the first page, so later pages rank the same posts
+type postPageCursor struct {
+	Sort  repository.PostSort `json:"s"`
+	Time  int64               `json:"t,omitempty"`
+	Score float64             `json:"v,omitempty"`
+	ID    int64               `json:"i"`
+	Since int64               `json:"w,omitempty"`
+}
+
+// listCursor is the encoded form of a repository.Cursor
+type listCursor struct {
+	CreatedAt int64 `json:"t"`
+	ID        int64 `json:"i"`
+}
+
+// parsePagination reads the limit and cursor query parameters of a list endpoint
+// Errors are meant to be returned to the client as a 400
+func parsePagination(r *http.Request) (*repository.Cursor, int32, error) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	raw := r.URL.Query().Get("cursor")
+	if raw == "" {
+		return nil, limit, nil
+	}
+
+	var c listCursor
+	if err := decodeCursor(raw, &c); err != nil || c.ID <= 0 {
+		return nil, 0, errInvalidCursor
+	}
+	return &repository.Cursor{CreatedAt: time.UnixMicro(c.CreatedAt), ID: c.ID}, limit, nil
+}
+
+// parsePostListOptions reads the sort, window, limit and cursor query parameters of a post listing
+// defaultSort applies when no sort is requested, errors are meant to be returned to the client as a 400
+func parsePostListOptions(r *http.Request, defaultSort repository.PostSort) (repository.PostListOptions, error) {
+	query := r.URL.Query()
+	opts := repository.PostListOptions{Sort: defaultSort}
+
+	if v := query.Get("sort"); v != "" {
+		opts.Sort = repository.PostSort(v)
+		switch opts.Sort {
+		case repository.PostSortNew, repository.PostSortTop, repository.PostSortHot, repository.PostSortActive:
+		default:
+			return opts, errors.New("sort must be new, top, hot or active")
+		}
+	}
+
+	if opts.Sort == repository.PostSortTop {
+		window := query.Get("window")
+		if window == "" {
+			window = defaultTopWindow
+		}
+		length, ok := topWindows[window]
+		if !ok {
+			return opts, errors.New("window must be day, week, month, year or all")
+		}
+		if length > 0 {
+			since := time.Now().Add(-length)
+			opts.Since = &since
+		}
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		return opts, err
+	}
+	opts.Limit = limit
+
+	if raw := query.Get("cursor"); raw != "" {
+		var c postPageCursor
+		if err := decodeCursor(raw, &c); err != nil || c.ID <= 0 {
+			return opts, errInvalidCursor
+		}
+		if c.Sort != opts.Sort {
+			return opts, errors.New("cursor belongs to a different sort")
+		}
+		opts.After = &repository.PostCursor{Time: time.UnixMicro(c.Time), Score: c.Score, ID: c.ID}
+		// The window moves with the clock, later pages keep the start of the first one
+		if opts.Sort == repository.PostSortTop {
+			opts.Since = nil
+			if c.Since != 0 {
+				since := time.UnixMicro(c.Since)
+				opts.Since = &since
+			}
+		}
+	}
+	return opts, nil
+}
+
+// encodePostCursor turns the next page position of a post listing into a cursor token, nil on the last page
+func encodePostCursor(opts repository.PostListOptions, c *repository.PostCursor) *string {
+	if c == nil {
+		return nil
+	}
+	pc := postPageCursor{Sort: opts.Sort, Score: c.Score, ID: c.ID}
+	if !c.Time.IsZero() {
+		pc.Time = c.Time.UnixMicro()
+	}
+	if opts.Sort == repository.PostSortTop && opts.Since != nil {
+		pc.Since = opts.Since.UnixMicro()
+	}
+	next := encodeCursor(pc)
+	return &next
+}
+
+// parseLimit validates the requested page size
+// Offsets are rejected so old clients notice the switch to cursors instead of looping on the first page
+func parseLimit(r *http.Request) (int32, error) {
+	query := r.URL.Query()
+	if query.Has("offset") {
+		return 0, errors.New("offset is not supported, use cursor")
+	}
+
+	l := query.Get("limit")
+	if l == "" {
+		return defaultPageSize, nil
+	}
+	v, err := strconv.ParseInt(l, 10, 32)
+	if err != nil {
+		return 0, errors.New("invalid limit")
+	}
+	if v < 1 || v > maxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+	return int32(v), nil
+}
+
+// paginate trims the extra row fetched to detect a following page and returns the cursor pointing at it
+// Callers query limit+1 rows, the cursor is nil on the last page
+func paginate[T any](items []T, limit int32, cursorOf func(T) repository.Cursor) ([]T, *string) {
+	if int32(len(items)) <= limit {
+		return items, nil
+	}
+	items = items[:limit]
+	c := cursorOf(items[len(items)-1])
+	next := encodeCursor(listCursor{CreatedAt: c.CreatedAt.UnixMicro(), ID: c.ID})
+	return items, &next
+}
+
+// encodeCursor turns a cursor value into an opaque URL-safe token
+func encodeCursor(v any) string {
+	b, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor reads back a token produced by encodeCursor
+func decodeCursor(raw string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return errInvalidCursor
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return errInvalidCursor
+	}
+	return nil
+}
+
+// commentCursor returns the pagination position of a comment
+func commentCursor(c *entity.Comment) repository.Cursor {
+	return repository.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+}
+
+// notificationCursor returns the pagination position of a notification
+func notificationCursor(n *entity.Notification) repository.Cursor {
+	return repository.Cursor{CreatedAt: n.CreatedAt, ID: n.ID}
+}
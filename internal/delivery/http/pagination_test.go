@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"my-chi-app/internal/database/repository"
+)
+
+func TestTopCursorKeepsWindow(t *testing.T) {
+	first, err := parsePostListOptions(httptest.NewRequest("GET", "/posts?sort=top&window=week", nil), repository.PostSortNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Since == nil {
+		t.Fatal("week window has no start")
+	}
+
+	next := encodePostCursor(first, &repository.PostCursor{Score: 12, ID: 7})
+	time.Sleep(time.Millisecond)
+
+	second, err := parsePostListOptions(httptest.NewRequest("GET", "/posts?sort=top&window=week&cursor="+url.QueryEscape(*next), nil), repository.PostSortNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Since == nil || !second.Since.Equal(time.UnixMicro(first.Since.UnixMicro())) {
+		t.Fatalf("second page window starts at %v, want the first page start %v", second.Since, first.Since)
+	}
+	if second.After == nil || second.After.Score != 12 || second.After.ID != 7 {
+		t.Fatalf("second page starts after %+v, want score 12 and ID 7", second.After)
+	}
+
+	// A cursor of the all time window stays unbounded
+	all, err := parsePostListOptions(httptest.NewRequest("GET", "/posts?sort=top&window=all", nil), repository.PostSortNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next = encodePostCursor(all, &repository.PostCursor{Score: 3, ID: 2})
+	second, err = parsePostListOptions(httptest.NewRequest("GET", "/posts?sort=top&cursor="+url.QueryEscape(*next), nil), repository.PostSortNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Since != nil {
+		t.Fatalf("second page of the all time window starts at %v, want no start", second.Since)
+	}
+}
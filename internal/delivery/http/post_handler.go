@@ -10,6 +10,8 @@ import (
 
 	"my-chi-app/internal/database/repository"
 	"my-chi-app/internal/domain/entity"
+	"my-chi-app/internal/metrics"
+	"my-chi-app/internal/notification"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -42,15 +44,16 @@ type ReactToPostRequest struct {
 
 // PostResponse is the payload response when returning post information
 type PostResponse struct {
-	PostID        int64         `json:"post_id"`
-	Headline      string        `json:"headline"`
-	Text          *string       `json:"text,omitempty"`
-	Image         *string       `json:"image,omitempty"`
-	CreatedAt     string        `json:"created_at"`
-	UpdatedAt     string        `json:"updated_at"`
-	IsEdited      bool          `json:"is_edited"`
-	TotalReaction int64         `json:"total_reaction"`
-	UserReaction  *ReactionInfo `json:"user_reaction"`
+	PostID        int64           `json:"post_id"`
+	Headline      string          `json:"headline"`
+	Text          *string         `json:"text,omitempty"`
+	Image         *string         `json:"image,omitempty"`
+	CreatedAt     string          `json:"created_at"`
+	UpdatedAt     string          `json:"updated_at"`
+	IsEdited      bool            `json:"is_edited"`
+	TotalReaction int64           `json:"total_reaction"`
+	Reactions     []ReactionCount `json:"reactions"`
+	UserReaction  *ReactionInfo   `json:"user_reaction"`
 }
 
 // @Summary Get posts by category
@@ -58,12 +61,15 @@ type PostResponse struct {
 // @Tags posts
 // @Security Bearer
 // @Param category_id path int true "Category ID"
-// @Param limit query int false "Limit" default(1000)
-// @Param offset query int false "Offset" default(0)
+// @Param sort query string false "new, top, hot or active" default(new)
+// @Param window query string false "Time window of the top sort: day, week, month, year or all, later pages keep the window of their cursor" default(week)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {array} PostResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /categories/{category_id}/posts [get]
-func HandleGetPostsByCategory(postRepo *repository.PostRepository, reactionRepo *repository.ReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+func HandleGetPostsByCategory(postRepo *repository.PostRepository, reactionRepo *repository.ReactionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -78,52 +84,89 @@ func HandleGetPostsByCategory(postRepo *repository.PostRepository, reactionRepo
 			return
 		}
 
+		opts, err := parsePostListOptions(r, repository.PostSortNew)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
+		}
+
 		ctx := r.Context()
 
 		// Paginated posts by category
-		posts, err := postRepo.GetByCategory(ctx, categoryID, 1000, 0)
+		posts, next, err := postRepo.GetByCategory(ctx, categoryID, opts)
 		if err != nil {
-			InternalError(w, "failed to fetch posts")
+			InternalError(w, r, "failed to fetch posts", err)
 			return
 		}
 
-		response := make([]PostResponse, len(posts))
-		for i, post := range posts {
-			response[i] = PostResponse{
-				PostID:    post.ID,
-				Headline:  post.Headline,
-				Text:      post.Text,
-				Image:     post.Image,
-				CreatedAt: post.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-				UpdatedAt: post.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-				IsEdited:  post.Status,
-			}
+		response, err := buildPostResponses(ctx, posts, userID, reactionRepo)
+		if err != nil {
+			InternalError(w, r, "failed to fetch reactions", err)
+			return
+		}
+		Paginated(w, response, encodePostCursor(opts, next))
+	}
+}
 
-			totalReactions, err := reactionRepo.CountByPost(ctx, post.ID)
-			if err == nil {
-				response[i].TotalReaction = totalReactions
-			}
+// @Summary Get home feed
+// @Description Fetch posts from every category the authenticated user subscribed to.
+// @Description Users without subscriptions get popular posts from the whole site instead.
+// @Tags posts
+// @Security Bearer
+// @Param sort query string false "new, top, hot or active" default(hot)
+// @Param window query string false "Time window of the top sort: day, week, month, year or all, later pages keep the window of their cursor" default(week)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {array} PostResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /feed [get]
+func HandleGetFeed(postRepo *repository.PostRepository, membershipRepo *repository.MembershipRepository, reactionRepo *repository.ReactionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			Unauthorized(w, "user not authenticated")
+			return
+		}
+
+		opts, err := parsePostListOptions(r, repository.PostSortHot)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
+		}
 
-			userReaction, err := reactionRepo.GetByOwnerAndPost(ctx, userID, post.ID)
-			if err == nil && userReaction != nil {
-				reactionType, err := reactionTypeRepo.GetByID(ctx, userReaction.ReactionTypeID)
-				if err == nil {
-					response[i].UserReaction = &ReactionInfo{
-						ReactionTypeID: reactionType.ID,
-						Name:           reactionType.Name,
-						Image:          reactionType.Image,
-					}
-				}
-			} else if err != nil && userReaction == nil {
-				response[i].UserReaction = &ReactionInfo{
-					ReactionTypeID: 0,
-					Name:           "",
-					Image:          nil,
-				}
+		ctx := r.Context()
+
+		memberships, err := membershipRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			InternalError(w, r, "failed to fetch subscriptions", err)
+			return
+		}
+
+		var (
+			posts []*entity.Post
+			next  *repository.PostCursor
+		)
+		if len(memberships) == 0 {
+			posts, next, err = postRepo.List(ctx, opts)
+		} else {
+			categoryIDs := make([]int64, len(memberships))
+			for i, m := range memberships {
+				categoryIDs[i] = m.CategoryID
 			}
+			posts, next, err = postRepo.GetByCategories(ctx, categoryIDs, opts)
+		}
+		if err != nil {
+			InternalError(w, r, "failed to fetch posts", err)
+			return
 		}
 
-		Success(w, response)
+		response, err := buildPostResponses(ctx, posts, userID, reactionRepo)
+		if err != nil {
+			InternalError(w, r, "failed to fetch reactions", err)
+			return
+		}
+		Paginated(w, response, encodePostCursor(opts, next))
 	}
 }
 
@@ -131,12 +174,15 @@ func HandleGetPostsByCategory(postRepo *repository.PostRepository, reactionRepo
 // @Description Fetch all posts created by the authenticated user
 // @Tags posts
 // @Security Bearer
-// @Param limit query int false "Limit" default(1000)
-// @Param offset query int false "Offset" default(0)
+// @Param sort query string false "new, top, hot or active" default(new)
+// @Param window query string false "Time window of the top sort: day, week, month, year or all, later pages keep the window of their cursor" default(week)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {array} PostResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /user/posts [get]
-func HandleGetUserPosts(postRepo *repository.PostRepository, reactionRepo *repository.ReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+func HandleGetUserPosts(postRepo *repository.PostRepository, reactionRepo *repository.ReactionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -144,16 +190,26 @@ func HandleGetUserPosts(postRepo *repository.PostRepository, reactionRepo *repos
 			return
 		}
 
+		opts, err := parsePostListOptions(r, repository.PostSortNew)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
+		}
+
 		ctx := r.Context()
 
-		posts, err := postRepo.GetByOwner(ctx, userID, 1000, 0)
+		posts, next, err := postRepo.GetByOwner(ctx, userID, opts)
 		if err != nil {
-			InternalError(w, "failed to fetch posts")
+			InternalError(w, r, "failed to fetch posts", err)
 			return
 		}
 
-		response := buildPostResponses(ctx, posts, userID, reactionRepo, reactionTypeRepo)
-		Success(w, response)
+		response, err := buildPostResponses(ctx, posts, userID, reactionRepo)
+		if err != nil {
+			InternalError(w, r, "failed to fetch reactions", err)
+			return
+		}
+		Paginated(w, response, encodePostCursor(opts, next))
 	}
 }
 
@@ -162,12 +218,15 @@ func HandleGetUserPosts(postRepo *repository.PostRepository, reactionRepo *repos
 // @Tags posts
 // @Security Bearer
 // @Param category_id path int true "Category ID"
-// @Param limit query int false "Limit" default(1000)
-// @Param offset query int false "Offset" default(0)
+// @Param sort query string false "new, top, hot or active" default(new)
+// @Param window query string false "Time window of the top sort: day, week, month, year or all, later pages keep the window of their cursor" default(week)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {array} PostResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /categories/{category_id}/posts/user [get]
-func HandleGetUserPostsByCategory(postRepo *repository.PostRepository, reactionRepo *repository.ReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+func HandleGetUserPostsByCategory(postRepo *repository.PostRepository, reactionRepo *repository.ReactionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -182,17 +241,27 @@ func HandleGetUserPostsByCategory(postRepo *repository.PostRepository, reactionR
 			return
 		}
 
+		opts, err := parsePostListOptions(r, repository.PostSortNew)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
+		}
+
 		ctx := r.Context()
 
 		// Paginated user's posts from that category
-		posts, err := postRepo.GetByOwnerAndCategory(ctx, userID, categoryID, 1000, 0)
+		posts, next, err := postRepo.GetByOwnerAndCategory(ctx, userID, categoryID, opts)
 		if err != nil {
-			InternalError(w, "failed to fetch posts")
+			InternalError(w, r, "failed to fetch posts", err)
 			return
 		}
 
-		response := buildPostResponses(ctx, posts, userID, reactionRepo, reactionTypeRepo)
-		Success(w, response)
+		response, err := buildPostResponses(ctx, posts, userID, reactionRepo)
+		if err != nil {
+			InternalError(w, r, "failed to fetch reactions", err)
+			return
+		}
+		Paginated(w, response, encodePostCursor(opts, next))
 	}
 }
 
@@ -205,7 +274,7 @@ func HandleGetUserPostsByCategory(postRepo *repository.PostRepository, reactionR
 // @Failure 401 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /posts/{post_id} [get]
-func HandleGetPost(postRepo *repository.PostRepository, reactionRepo *repository.ReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+func HandleGetPost(postRepo *repository.PostRepository, reactionRepo *repository.ReactionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -228,44 +297,17 @@ func HandleGetPost(postRepo *repository.PostRepository, reactionRepo *repository
 				NotFound(w, "post not found")
 				return
 			}
-			InternalError(w, "failed to fetch post")
+			InternalError(w, r, "failed to fetch post", err)
 			return
 		}
 
-		response := PostResponse{
-			PostID:    post.ID,
-			Headline:  post.Headline,
-			Text:      post.Text,
-			Image:     post.Image,
-			CreatedAt: post.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt: post.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			IsEdited:  post.Status,
-		}
-
-		totalReactions, err := reactionRepo.CountByPost(ctx, post.ID)
-		if err == nil {
-			response.TotalReaction = totalReactions
-		}
-
-		userReaction, err := reactionRepo.GetByOwnerAndPost(ctx, userID, post.ID)
-		if err == nil && userReaction != nil {
-			reactionType, err := reactionTypeRepo.GetByID(ctx, userReaction.ReactionTypeID)
-			if err == nil {
-				response.UserReaction = &ReactionInfo{
-					ReactionTypeID: reactionType.ID,
-					Name:           reactionType.Name,
-					Image:          reactionType.Image,
-				}
-			}
-		} else if err != nil && userReaction == nil {
-			response.UserReaction = &ReactionInfo{
-				ReactionTypeID: 0,
-				Name:           "",
-				Image:          nil,
-			}
+		response, err := buildPostResponses(ctx, []*entity.Post{post}, userID, reactionRepo)
+		if err != nil {
+			InternalError(w, r, "failed to fetch reactions", err)
+			return
 		}
 
-		Success(w, response)
+		Success(w, response[0])
 	}
 }
 
@@ -278,8 +320,9 @@ func HandleGetPost(postRepo *repository.PostRepository, reactionRepo *repository
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /categories/{category_id}/posts [post]
-func HandleCreatePost(postRepo *repository.PostRepository) http.HandlerFunc {
+func HandleCreatePost(postRepo *repository.PostRepository, appMetrics *metrics.Metrics) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -318,9 +361,10 @@ func HandleCreatePost(postRepo *repository.PostRepository) http.HandlerFunc {
 
 		post, err = postRepo.Create(ctx, post)
 		if err != nil {
-			InternalError(w, "failed to create post")
+			InternalError(w, r, "failed to create post", err)
 			return
 		}
+		appMetrics.PostCreated()
 
 		Success(w, MessageResponse{
 			Message: "Post created successfully!",
@@ -339,7 +383,7 @@ func HandleCreatePost(postRepo *repository.PostRepository) http.HandlerFunc {
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Router /posts/{post_id} [put]
-func HandleUpdatePost(postRepo *repository.PostRepository) http.HandlerFunc {
+func HandleUpdatePost(postRepo *repository.PostRepository, moderatorRepo *repository.ModeratorRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -373,13 +417,20 @@ func HandleUpdatePost(postRepo *repository.PostRepository) http.HandlerFunc {
 				NotFound(w, "post not found")
 				return
 			}
-			InternalError(w, "failed to fetch post")
+			InternalError(w, r, "failed to fetch post", err)
 			return
 		}
 
 		if post.OwnerID != userID {
-			Forbidden(w, "you can only update your own posts")
-			return
+			allowed, err := canModerate(ctx, moderatorRepo, post.CategoryID)
+			if err != nil {
+				InternalError(w, r, "failed to check permissions", err)
+				return
+			}
+			if !allowed {
+				Forbidden(w, "you can only update your own posts")
+				return
+			}
 		}
 
 		post.Headline = req.Headline
@@ -387,7 +438,7 @@ func HandleUpdatePost(postRepo *repository.PostRepository) http.HandlerFunc {
 		post.Image = req.Image
 
 		if err := postRepo.Update(ctx, post); err != nil {
-			InternalError(w, "failed to update post")
+			InternalError(w, r, "failed to update post", err)
 			return
 		}
 
@@ -406,7 +457,7 @@ func HandleUpdatePost(postRepo *repository.PostRepository) http.HandlerFunc {
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Router /posts/{post_id} [delete]
-func HandleDeletePost(postRepo *repository.PostRepository) http.HandlerFunc {
+func HandleDeletePost(postRepo *repository.PostRepository, moderatorRepo *repository.ModeratorRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -429,17 +480,24 @@ func HandleDeletePost(postRepo *repository.PostRepository) http.HandlerFunc {
 				NotFound(w, "post not found")
 				return
 			}
-			InternalError(w, "failed to fetch post")
+			InternalError(w, r, "failed to fetch post", err)
 			return
 		}
 
 		if post.OwnerID != userID {
-			Forbidden(w, "you can only delete your own posts")
-			return
+			allowed, err := canModerate(ctx, moderatorRepo, post.CategoryID)
+			if err != nil {
+				InternalError(w, r, "failed to check permissions", err)
+				return
+			}
+			if !allowed {
+				Forbidden(w, "you can only delete your own posts")
+				return
+			}
 		}
 
 		if err := postRepo.Delete(ctx, postID); err != nil {
-			InternalError(w, "failed to delete post")
+			InternalError(w, r, "failed to delete post", err)
 			return
 		}
 
@@ -455,11 +513,13 @@ func HandleDeletePost(postRepo *repository.PostRepository) http.HandlerFunc {
 // @Security Bearer
 // @Param post_id path int true "Post ID"
 // @Param request body ReactToPostRequest true "Reaction type"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} ReactionSummaryResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /posts/{post_id}/react [post]
-func HandleReactToPost(reactionRepo *repository.ReactionRepository) http.HandlerFunc {
+func HandleReactToPost(postRepo *repository.PostRepository, reactionRepo *repository.ReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository, notifier *notification.Dispatcher, appMetrics *metrics.Metrics) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 		if !ok {
@@ -480,13 +540,38 @@ func HandleReactToPost(reactionRepo *repository.ReactionRepository) http.Handler
 			return
 		}
 
-		if req.ReactionTypeID == 0 {
+		if req.ReactionTypeID <= 0 {
 			ValidationError(w, "reaction_type_id is required")
 			return
 		}
 
 		ctx := r.Context()
 
+		post, err := postRepo.GetByID(ctx, postID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "post not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch post", err)
+			return
+		}
+
+		reactionType, err := reactionTypeRepo.GetByID(ctx, req.ReactionTypeID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "reaction type not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch reaction type", err)
+			return
+		}
+
+		if reactionType.RetiredAt != nil {
+			ValidationError(w, "reaction type is retired")
+			return
+		}
+
 		reaction := &entity.Reaction{
 			PostID:         postID,
 			OwnerID:        userID,
@@ -495,46 +580,109 @@ func HandleReactToPost(reactionRepo *repository.ReactionRepository) http.Handler
 
 		_, err = reactionRepo.Upsert(ctx, reaction)
 		if err != nil {
-			InternalError(w, "failed to record reaction")
+			InternalError(w, r, "failed to record reaction", err)
 			return
 		}
+		appMetrics.ReactionCreated("post")
 
-		Success(w, MessageResponse{
-			Message: "Reaction recorded!",
-		})
+		notifier.ReactToPost(ctx, userID, post)
+
+		summary, err := buildPostReactionSummary(ctx, reactionRepo, postID, userID)
+		if err != nil {
+			InternalError(w, r, "failed to fetch reactions", err)
+			return
+		}
+		Success(w, summary)
+	}
+}
+
+// @Summary Remove reaction from a post
+// @Description Take back the authenticated user's reaction to a specific post
+// @Tags posts
+// @Security Bearer
+// @Param post_id path int true "Post ID"
+// @Success 200 {object} ReactionSummaryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /posts/{post_id}/react [delete]
+func HandleRemovePostReaction(reactionRepo *repository.ReactionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			Unauthorized(w, "user not authenticated")
+			return
+		}
+
+		postIDStr := chi.URLParam(r, "post_id")
+		postID, err := strconv.ParseInt(postIDStr, 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid post_id")
+			return
+		}
+
+		ctx := r.Context()
+
+		if err := reactionRepo.DeleteByOwnerAndPost(ctx, userID, postID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "reaction not found")
+				return
+			}
+			InternalError(w, r, "failed to remove reaction", err)
+			return
+		}
+
+		summary, err := buildPostReactionSummary(ctx, reactionRepo, postID, userID)
+		if err != nil {
+			InternalError(w, r, "failed to fetch reactions", err)
+			return
+		}
+		Success(w, summary)
 	}
 }
 
 // buildPostResponses converts post entities to PostResponse with reaction details
-// Includes total reactions and user's reaction
-func buildPostResponses(ctx context.Context, posts []*entity.Post, userID int64, reactionRepo *repository.ReactionRepository, reactionTypeRepo *repository.ReactionTypeRepository) []PostResponse {
+// Includes total reactions, counts per reaction type and user's reaction, loaded with one query each for the whole page
+func buildPostResponses(ctx context.Context, posts []*entity.Post, userID int64, reactionRepo *repository.ReactionRepository) ([]PostResponse, error) {
 	response := make([]PostResponse, len(posts))
+	if len(posts) == 0 {
+		return response, nil
+	}
+
+	postIDs := make([]int64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	counts, err := reactionRepo.CountTypesByPosts(ctx, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	userReactions, err := reactionRepo.GetTypesByOwnerAndPosts(ctx, userID, postIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	for i, post := range posts {
+		reactions, total := toReactionCounts(counts[post.ID])
 		response[i] = PostResponse{
-			PostID:    post.ID,
-			Headline:  post.Headline,
-			Text:      post.Text,
-			Image:     post.Image,
-			CreatedAt: post.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt: post.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			IsEdited:  post.Status,
-		}
-
-		totalReactions, err := reactionRepo.CountByPost(ctx, post.ID)
-		if err == nil {
-			response[i].TotalReaction = totalReactions
-		}
-
-		userReaction, err := reactionRepo.GetByOwnerAndPost(ctx, userID, post.ID)
-		if err == nil && userReaction != nil {
-			reactionType, err := reactionTypeRepo.GetByID(ctx, userReaction.ReactionTypeID)
-			if err == nil {
-				response[i].UserReaction = &ReactionInfo{
-					ReactionTypeID: reactionType.ID,
-					Name:           reactionType.Name,
-				}
+			PostID:        post.ID,
+			Headline:      post.Headline,
+			Text:          post.Text,
+			Image:         post.Image,
+			CreatedAt:     post.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:     post.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			IsEdited:      post.Status,
+			TotalReaction: total,
+			Reactions:     reactions,
+		}
+		if rt, ok := userReactions[post.ID]; ok {
+			response[i].UserReaction = &ReactionInfo{
+				ReactionTypeID: rt.ID,
+				Name:           rt.Name,
+				Image:          rt.Image,
 			}
 		}
 	}
-	return response
+	return response, nil
 }
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+)
+
+// pageSizes are the page sizes the query count must not depend on
+var pageSizes = []int{1, 20, 100}
+
+// testPosts returns n posts of n different owners
+func testPosts(n int) []*entity.Post {
+	posts := make([]*entity.Post, n)
+	for i := range posts {
+		posts[i] = &entity.Post{ID: int64(i + 1), OwnerID: int64(i + 1), Headline: "headline"}
+	}
+	return posts
+}
+
+// testComments returns n comments of n different owners
+func testComments(n int) []*entity.Comment {
+	comments := make([]*entity.Comment, n)
+	for i := range comments {
+		comments[i] = &entity.Comment{ID: int64(i + 1), PostID: 1, OwnerID: int64(i + 1), Text: "text"}
+	}
+	return comments
+}
+
+// queriesPerPage builds one page of each size and returns the number of queries each page ran
+func queriesPerPage(t testing.TB, build func(db *sql.DB, size int) error) []int64 {
+	counts := make([]int64, len(pageSizes))
+	for i, size := range pageSizes {
+		connector := &countingConnector{}
+		db := sql.OpenDB(connector)
+		if err := build(db, size); err != nil {
+			t.Fatalf("page of %d: %v", size, err)
+		}
+		db.Close()
+		counts[i] = connector.queries.Load()
+	}
+	return counts
+}
+
+func assertConstant(t *testing.T, counts []int64) {
+	t.Helper()
+	for i, count := range counts {
+		if count != counts[0] {
+			t.Fatalf("page of %d ran %d queries, page of %d ran %d", pageSizes[i], count, pageSizes[0], counts[0])
+		}
+	}
+}
+
+func buildPostPage(db *sql.DB, size int) error {
+	responses, err := buildPostResponses(context.Background(), testPosts(size), 1, repository.NewReactionRepository(db))
+	if err == nil && (len(responses) != size || responses[size-1].UserReaction == nil) {
+		err = fmt.Errorf("incomplete responses")
+	}
+	return err
+}
+
+func buildCommentPage(db *sql.DB, size int) error {
+	responses, err := buildCommentResponses(context.Background(), testComments(size), 1,
+		repository.NewUserRepository(db), repository.NewCommentReactionRepository(db))
+	if err == nil && (len(responses) != size || responses[size-1].UserReaction == nil) {
+		err = fmt.Errorf("incomplete responses")
+	}
+	return err
+}
+
+func TestBuildPostResponsesQueryCount(t *testing.T) {
+	assertConstant(t, queriesPerPage(t, buildPostPage))
+}
+
+func TestBuildCommentResponsesQueryCount(t *testing.T) {
+	assertConstant(t, queriesPerPage(t, buildCommentPage))
+}
+
+func BenchmarkBuildPostResponses(b *testing.B) {
+	benchmarkPages(b, buildPostPage)
+}
+
+func BenchmarkBuildCommentResponses(b *testing.B) {
+	benchmarkPages(b, buildCommentPage)
+}
+
+// benchmarkPages reports the queries per page next to the timings of each page size
+func benchmarkPages(b *testing.B, build func(db *sql.DB, size int) error) {
+	for _, size := range pageSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			connector := &countingConnector{}
+			db := sql.OpenDB(connector)
+			defer db.Close()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := build(db, size); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(connector.queries.Load())/float64(b.N), "queries/op")
+		})
+	}
+}
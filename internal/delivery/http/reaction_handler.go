@@ -0,0 +1,248 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ReactionCount is the payload response for the number of reactions of one type
+type ReactionCount struct {
+	ReactionTypeID int64   `json:"reaction_type_id"`
+	Name           string  `json:"name"`
+	Image          *string `json:"image,omitempty"`
+	Count          int64   `json:"count"`
+}
+
+// ReactionSummaryResponse is the payload response with the reaction counts of a post or comment after a change
+type ReactionSummaryResponse struct {
+	TotalReaction int64           `json:"total_reaction"`
+	Reactions     []ReactionCount `json:"reactions"`
+	UserReaction  *ReactionInfo   `json:"user_reaction"`
+}
+
+// ReactorResponse is the payload response for a user who reacted to a post or comment
+type ReactorResponse struct {
+	UserID         int64        `json:"user_id"`
+	Username       string       `json:"username"`
+	ProfilePicture *string      `json:"profile_picture,omitempty"`
+	Reaction       ReactionInfo `json:"reaction"`
+	ReactedAt      string       `json:"reacted_at"`
+}
+
+// @Summary Get reactions to a post
+// @Description Fetch paginated users who reacted to a post with the reaction they chose, newest first
+// @Tags posts
+// @Security Bearer
+// @Param post_id path int true "Post ID"
+// @Param reaction_type_id query int false "Only list this reaction type"
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {array} ReactorResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /posts/{post_id}/reactions [get]
+func HandleGetPostReactions(postRepo *repository.PostRepository, reactionRepo *repository.ReactionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		postIDStr := chi.URLParam(r, "post_id")
+		postID, err := strconv.ParseInt(postIDStr, 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid post_id")
+			return
+		}
+
+		reactionTypeID, err := parseReactionTypeFilter(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
+		}
+
+		after, limit, err := parsePagination(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
+		}
+
+		ctx := r.Context()
+
+		if _, err := postRepo.GetByID(ctx, postID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "post not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch post", err)
+			return
+		}
+
+		reactors, err := reactionRepo.ListReactorsByPost(ctx, postID, reactionTypeID, after, limit+1)
+		if err != nil {
+			InternalError(w, r, "failed to fetch reactions", err)
+			return
+		}
+		reactors, next := paginate(reactors, limit, reactorCursor)
+
+		Paginated(w, toReactorResponses(reactors), next)
+	}
+}
+
+// @Summary Get reactions to a comment
+// @Description Fetch paginated users who reacted to a comment or reply with the reaction they chose, newest first
+// @Tags comments
+// @Security Bearer
+// @Param comment_id path int true "Comment ID"
+// @Param reaction_type_id query int false "Only list this reaction type"
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {array} ReactorResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /comments/{comment_id}/reactions [get]
+func HandleGetCommentReactions(commentRepo *repository.CommentRepository, commentReactionRepo *repository.CommentReactionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		commentIDStr := chi.URLParam(r, "comment_id")
+		commentID, err := strconv.ParseInt(commentIDStr, 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid comment_id")
+			return
+		}
+
+		reactionTypeID, err := parseReactionTypeFilter(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
+		}
+
+		after, limit, err := parsePagination(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
+		}
+
+		ctx := r.Context()
+
+		if _, err := commentRepo.GetByID(ctx, commentID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "comment not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch comment", err)
+			return
+		}
+
+		reactors, err := commentReactionRepo.ListReactorsByComment(ctx, commentID, reactionTypeID, after, limit+1)
+		if err != nil {
+			InternalError(w, r, "failed to fetch reactions", err)
+			return
+		}
+		reactors, next := paginate(reactors, limit, reactorCursor)
+
+		Paginated(w, toReactorResponses(reactors), next)
+	}
+}
+
+// parseReactionTypeFilter reads the optional reaction_type_id query parameter
+func parseReactionTypeFilter(r *http.Request) (*int64, error) {
+	v := r.URL.Query().Get("reaction_type_id")
+	if v == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid reaction_type_id")
+	}
+	return &id, nil
+}
+
+// reactorCursor returns the pagination position of a reactor
+func reactorCursor(rr *repository.Reactor) repository.Cursor {
+	return repository.Cursor{CreatedAt: rr.CreatedAt, ID: rr.ReactionID}
+}
+
+// toReactorResponses converts reactors to their payload response
+func toReactorResponses(reactors []*repository.Reactor) []ReactorResponse {
+	response := make([]ReactorResponse, len(reactors))
+	for i, rr := range reactors {
+		response[i] = ReactorResponse{
+			UserID:         rr.UserID,
+			Username:       rr.Username,
+			ProfilePicture: rr.ProfilePicture,
+			Reaction: ReactionInfo{
+				ReactionTypeID: rr.Type.ID,
+				Name:           rr.Type.Name,
+				Image:          rr.Type.Image,
+			},
+			ReactedAt: rr.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+	return response
+}
+
+// buildPostReactionSummary loads the current reaction counts of a post and the user's reaction
+func buildPostReactionSummary(ctx context.Context, reactionRepo *repository.ReactionRepository, postID, userID int64) (*ReactionSummaryResponse, error) {
+	postIDs := []int64{postID}
+	counts, err := reactionRepo.CountTypesByPosts(ctx, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	userReactions, err := reactionRepo.GetTypesByOwnerAndPosts(ctx, userID, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	return toReactionSummary(counts[postID], userReactions[postID]), nil
+}
+
+// buildCommentReactionSummary loads the current reaction counts of a comment and the user's reaction
+func buildCommentReactionSummary(ctx context.Context, commentReactionRepo *repository.CommentReactionRepository, commentID, userID int64) (*ReactionSummaryResponse, error) {
+	commentIDs := []int64{commentID}
+	counts, err := commentReactionRepo.CountTypesByComments(ctx, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+	userReactions, err := commentReactionRepo.GetTypesByOwnerAndComments(ctx, userID, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+	return toReactionSummary(counts[commentID], userReactions[commentID]), nil
+}
+
+// toReactionSummary converts counts and the user's reaction type to a ReactionSummaryResponse
+func toReactionSummary(counts []*repository.ReactionTypeCount, userReaction *entity.ReactionType) *ReactionSummaryResponse {
+	reactions, total := toReactionCounts(counts)
+	summary := &ReactionSummaryResponse{
+		TotalReaction: total,
+		Reactions:     reactions,
+	}
+	if userReaction != nil {
+		summary.UserReaction = &ReactionInfo{
+			ReactionTypeID: userReaction.ID,
+			Name:           userReaction.Name,
+			Image:          userReaction.Image,
+		}
+	}
+	return summary
+}
+
+// toReactionCounts converts per type counts to their payload response and sums them up
+func toReactionCounts(counts []*repository.ReactionTypeCount) ([]ReactionCount, int64) {
+	response := make([]ReactionCount, len(counts))
+	var total int64
+	for i, c := range counts {
+		response[i] = ReactionCount{
+			ReactionTypeID: c.Type.ID,
+			Name:           c.Type.Name,
+			Image:          c.Type.Image,
+			Count:          c.Count,
+		}
+		total += c.Count
+	}
+	return response, total
+}
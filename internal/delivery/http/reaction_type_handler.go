@@ -0,0 +1,315 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+	"my-chi-app/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ReactionTypeRequest is the payload for creating or updating a reaction type
+// Image is the URL of the icon, typically the image_url returned by the icon presign endpoint
+type ReactionTypeRequest struct {
+	Name  string  `json:"name"`
+	Image *string `json:"image"`
+}
+
+// ReactionTypeResponse is the payload response when returning reaction type information
+type ReactionTypeResponse struct {
+	ReactionTypeID int64   `json:"reaction_type_id"`
+	Name           string  `json:"name"`
+	Image          *string `json:"image,omitempty"`
+	RetiredAt      *string `json:"retired_at,omitempty"`
+}
+
+// ReactionTypeIconUploadResponse is the payload response for uploading a reaction type icon
+type ReactionTypeIconUploadResponse struct {
+	PresignedURL string `json:"presigned_url"`
+	ImageURL     string `json:"image_url"`
+}
+
+// @Summary Get reaction types
+// @Description Retrieve the reaction types clients can offer, retired types are only listed on request
+// @Tags reaction-types
+// @Param include_retired query bool false "Also list retired reaction types"
+// @Success 200 {array} ReactionTypeResponse
+// @Failure 400 {object} map[string]string
+// @Router /reaction-types [get]
+func HandleGetReactionTypes(reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		includeRetired := false
+		if v := r.URL.Query().Get("include_retired"); v != "" {
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				BadRequest(w, "invalid include_retired")
+				return
+			}
+			includeRetired = parsed
+		}
+
+		reactionTypes, err := reactionTypeRepo.List(r.Context(), includeRetired)
+		if err != nil {
+			InternalError(w, r, "failed to fetch reaction types", err)
+			return
+		}
+
+		response := make([]ReactionTypeResponse, len(reactionTypes))
+		for i, rt := range reactionTypes {
+			response[i] = toReactionTypeResponse(rt)
+		}
+
+		// Reaction types rarely change, let browsers and proxies keep them for a while
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		Success(w, response)
+	}
+}
+
+// @Summary Create a reaction type
+// @Description Add a new reaction type (admin only)
+// @Tags reaction-types
+// @Security Bearer
+// @Param request body ReactionTypeRequest true "Reaction type data"
+// @Success 201 {object} ReactionTypeResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /reaction-types [post]
+func HandleCreateReactionType(reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReactionTypeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "invalid request body")
+			return
+		}
+
+		if msg := validateReactionTypeRequest(&req); msg != "" {
+			ValidationError(w, msg)
+			return
+		}
+
+		rt := &entity.ReactionType{Name: req.Name, Image: req.Image}
+		if _, err := reactionTypeRepo.Create(r.Context(), rt); err != nil {
+			if isDuplicateError(err) {
+				Conflict(w, "reaction type already exists")
+				return
+			}
+			InternalError(w, r, "failed to create reaction type", err)
+			return
+		}
+
+		Created(w, toReactionTypeResponse(rt))
+	}
+}
+
+// @Summary Update a reaction type
+// @Description Change the name and icon of a reaction type (admin only)
+// @Tags reaction-types
+// @Security Bearer
+// @Param reaction_type_id path int true "Reaction type ID"
+// @Param request body ReactionTypeRequest true "Reaction type data"
+// @Success 200 {object} ReactionTypeResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /reaction-types/{reaction_type_id} [put]
+func HandleUpdateReactionType(reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reactionTypeID, err := strconv.ParseInt(chi.URLParam(r, "reaction_type_id"), 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid reaction_type_id")
+			return
+		}
+
+		var req ReactionTypeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "invalid request body")
+			return
+		}
+
+		if msg := validateReactionTypeRequest(&req); msg != "" {
+			ValidationError(w, msg)
+			return
+		}
+
+		ctx := r.Context()
+
+		rt, err := reactionTypeRepo.GetByID(ctx, reactionTypeID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "reaction type not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch reaction type", err)
+			return
+		}
+
+		rt.Name = req.Name
+		rt.Image = req.Image
+		if err := reactionTypeRepo.Update(ctx, rt); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "reaction type not found")
+				return
+			}
+			if isDuplicateError(err) {
+				Conflict(w, "reaction type already exists")
+				return
+			}
+			InternalError(w, r, "failed to update reaction type", err)
+			return
+		}
+
+		Success(w, toReactionTypeResponse(rt))
+	}
+}
+
+// @Summary Retire a reaction type
+// @Description Stop accepting new reactions of this type (admin only), existing reactions are kept
+// @Tags reaction-types
+// @Security Bearer
+// @Param reaction_type_id path int true "Reaction type ID"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /reaction-types/{reaction_type_id} [delete]
+func HandleRetireReactionType(reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reactionTypeID, err := strconv.ParseInt(chi.URLParam(r, "reaction_type_id"), 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid reaction_type_id")
+			return
+		}
+
+		if err := reactionTypeRepo.Retire(r.Context(), reactionTypeID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "reaction type not found")
+				return
+			}
+			InternalError(w, r, "failed to retire reaction type", err)
+			return
+		}
+
+		Success(w, MessageResponse{
+			Message: "Reaction type retired successfully!",
+		})
+	}
+}
+
+// @Summary Restore a reaction type
+// @Description Accept new reactions of a retired type again (admin only)
+// @Tags reaction-types
+// @Security Bearer
+// @Param reaction_type_id path int true "Reaction type ID"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /reaction-types/{reaction_type_id}/restore [post]
+func HandleRestoreReactionType(reactionTypeRepo *repository.ReactionTypeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reactionTypeID, err := strconv.ParseInt(chi.URLParam(r, "reaction_type_id"), 10, 64)
+		if err != nil {
+			BadRequest(w, "invalid reaction_type_id")
+			return
+		}
+
+		if err := reactionTypeRepo.Restore(r.Context(), reactionTypeID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "reaction type not found")
+				return
+			}
+			InternalError(w, r, "failed to restore reaction type", err)
+			return
+		}
+
+		Success(w, MessageResponse{
+			Message: "Reaction type restored successfully!",
+		})
+	}
+}
+
+// @Summary Get reaction type icon upload URL
+// @Description Generate a presigned S3 URL for uploading a reaction type icon (admin only)
+// @Description Upload the image with PUT to presigned_url, then send image_url as the image of the reaction type
+// @Tags reaction-types
+// @Security Bearer
+// @Param file_name query string true "File name (e.g., like.png)"
+// @Success 200 {object} ReactionTypeIconUploadResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /reaction-types/icons/presign [post]
+func HandleGetReactionTypeIconUploadURL(s3Client *storage.S3Client, expiry time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fileName := r.URL.Query().Get("file_name")
+		if fileName == "" {
+			BadRequest(w, "file_name query parameter is required")
+			return
+		}
+		if strings.Contains(fileName, "/") {
+			BadRequest(w, "file_name must not contain a path")
+			return
+		}
+
+		// A random prefix keeps icons of different types apart and lets caches keep each one forever
+		token, err := randomToken(8)
+		if err != nil {
+			InternalError(w, r, "failed to generate upload key", err)
+			return
+		}
+		key := "reaction-types/" + token + "-" + fileName
+		presignedURL, err := s3Client.CreatePresignedUploadURL(r.Context(), key, expiry)
+		if err != nil {
+			InternalError(w, r, "failed to generate presigned URL", err)
+			return
+		}
+
+		Success(w, ReactionTypeIconUploadResponse{
+			PresignedURL: presignedURL,
+			ImageURL:     s3Client.GetObjectURL(key),
+		})
+	}
+}
+
+// validateReactionTypeRequest trims the request and returns a message describing the first invalid field
+func validateReactionTypeRequest(req *ReactionTypeRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "name is required"
+	}
+	if len(req.Name) > 100 {
+		return "name must be at most 100 characters"
+	}
+	if req.Image != nil && len(*req.Image) > 255 {
+		return "image must be at most 255 characters"
+	}
+	return ""
+}
+
+// toReactionTypeResponse converts a reaction type to its payload response
+func toReactionTypeResponse(rt *entity.ReactionType) ReactionTypeResponse {
+	response := ReactionTypeResponse{
+		ReactionTypeID: rt.ID,
+		Name:           rt.Name,
+		Image:          rt.Image,
+	}
+	if rt.RetiredAt != nil {
+		retiredAt := rt.RetiredAt.Format("2006-01-02T15:04:05Z07:00")
+		response.RetiredAt = &retiredAt
+	}
+	return response
+}
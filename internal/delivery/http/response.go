@@ -1,28 +1,56 @@
 package http
 
 import (
+	"bytes"
 	"encoding/json"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // Response is the standard API response wrapper
+// NextCursor is only set by list endpoints when another page is available
 type Response struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   *ErrorInfo  `json:"error,omitempty"`
+	Success    bool        `json:"success"`
+	Data       interface{} `json:"data,omitempty"`
+	NextCursor *string     `json:"next_cursor,omitempty"`
+	Error      *ErrorInfo  `json:"error,omitempty"`
 }
 
 // ErrorInfo contains error details
+// RequestID is set on internal errors so they can be matched with the server logs
 type ErrorInfo struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // JSON sends a JSON response with the given status code
+// The body is encoded before anything is written, so values refusing to be serialized
+// such as entity.User turn into a 500 instead of leaking partial data
 func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		slog.Error("failed to encode response", "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		buf.Reset()
+		json.NewEncoder(&buf).Encode(Response{
+			Success: false,
+			Error: &ErrorInfo{
+				Code:    "INTERNAL_ERROR",
+				Message: "failed to encode response",
+			},
+		})
+		w.Write(buf.Bytes())
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(data)
+	w.Write(buf.Bytes())
 }
 
 // Success sends a successful JSON response with data
@@ -33,6 +61,15 @@ func Success(w http.ResponseWriter, data interface{}) {
 	})
 }
 
+// Paginated sends a successful JSON response with one page of a list
+func Paginated(w http.ResponseWriter, data interface{}, nextCursor *string) {
+	JSON(w, http.StatusOK, Response{
+		Success:    true,
+		Data:       data,
+		NextCursor: nextCursor,
+	})
+}
+
 // Created sends a 201 Created response with data
 func Created(w http.ResponseWriter, data interface{}) {
 	JSON(w, http.StatusCreated, Response{
@@ -87,7 +124,32 @@ func ValidationError(w http.ResponseWriter, message string) {
 	Error(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", message)
 }
 
-// InternalError sends a 500 error response indicate internal server error
-func InternalError(w http.ResponseWriter, message string) {
-	Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+// TooManyRequests sends a 429 error response telling the client when to retry
+func TooManyRequests(w http.ResponseWriter, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	Error(w, http.StatusTooManyRequests, "RATE_LIMITED", message)
+}
+
+// InternalError logs msg with err and sends a 500 error response carrying only the request ID
+// err often holds database details and is never sent to the client
+func InternalError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	requestID := GetRequestID(r.Context())
+	if err != nil {
+		Logger(r.Context()).Error(msg, "error", err)
+	} else {
+		Logger(r.Context()).Error(msg)
+	}
+
+	JSON(w, http.StatusInternalServerError, Response{
+		Success: false,
+		Error: &ErrorInfo{
+			Code:      "INTERNAL_ERROR",
+			Message:   "internal server error",
+			RequestID: requestID,
+		},
+	})
 }
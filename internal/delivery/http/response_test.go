@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTooManyRequestsRetryAfter(t *testing.T) {
+	tests := []struct {
+		retryAfter time.Duration
+		want       string
+	}{
+		{retryAfter: 0, want: "1"},
+		{retryAfter: time.Millisecond, want: "1"},
+		{retryAfter: time.Second, want: "1"},
+		{retryAfter: 1200 * time.Millisecond, want: "2"},
+		{retryAfter: 90 * time.Second, want: "90"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		TooManyRequests(rec, tt.retryAfter, "slow down")
+		if rec.Code != http.StatusTooManyRequests {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+		}
+		if got := rec.Header().Get("Retry-After"); got != tt.want {
+			t.Errorf("Retry-After for %v = %q, want %q", tt.retryAfter, got, tt.want)
+		}
+	}
+}
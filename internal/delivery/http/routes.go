@@ -1,12 +1,18 @@
 package http
 
 import (
-	"net/http"
+	"log/slog"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
 	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+	"my-chi-app/internal/mail"
+	"my-chi-app/internal/metrics"
+	"my-chi-app/internal/notification"
+	"my-chi-app/internal/ratelimit"
 	"my-chi-app/internal/storage"
 )
 
@@ -22,86 +28,168 @@ type RouterDeps struct {
 	CommentRepo         *repository.CommentRepository
 	CommentReactionRepo *repository.CommentReactionRepository
 	NotificationRepo    *repository.NotificationRepository
+	AccountTokenRepo    *repository.AccountTokenRepository
+	ModeratorRepo       *repository.ModeratorRepository
+	SearchRepo          *repository.SearchRepository
+	Notifier            *notification.Dispatcher
+	NotificationBroker  notification.Broker
 	S3Client            *storage.S3Client
+	Mailer              mail.Mailer
+	Lifecycle           *Lifecycle
+	Metrics             *metrics.Metrics
+	ExposeMetrics       bool
+	ReadinessChecks     []ReadinessCheck
+	RateLimitStore      ratelimit.Store
+	RateLimits          RateLimitPolicies
+	TrustProxyHeaders   bool
+	AppBaseURL          string
 	JWTSecret           string
+	AccessTokenTTL      time.Duration
+	RefreshTokenTTL     time.Duration
+	PresignExpiry       time.Duration
 }
 
 // Routes constructs and returns the application router including all routes and middleware
 func Routes(deps RouterDeps) *chi.Mux {
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	// Only trust X-Forwarded-For behind a proxy that sets it, clients could spoof their IP otherwise
+	if deps.TrustProxyHeaders {
+		r.Use(middleware.RealIP)
+	}
+	r.Use(Trace)
+	r.Use(RequestLogger(slog.Default()))
+	r.Use(Recoverer)
+	r.Use(Instrument(deps.Metrics))
 	r.Use(CORS)
 
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("ok"))
+	tokenConfig := TokenConfig{
+		JWTSecret:       deps.JWTSecret,
+		AccessTokenTTL:  deps.AccessTokenTTL,
+		RefreshTokenTTL: deps.RefreshTokenTTL,
+	}
+
+	// Rate limiting is disabled when no store is configured
+	limiter := NewRateLimiter(deps.RateLimitStore, deps.RateLimits.Lockout)
+	limitPosts := limiter.Limit("post", deps.RateLimits.Post)
+	limitComments := limiter.Limit("comment", deps.RateLimits.Comment)
+	limitReactions := limiter.Limit("react", deps.RateLimits.React)
+
+	r.Get("/health", HandleHealth(deps.Lifecycle))
+	r.Get("/livez", HandleLivez())
+	r.Get("/readyz", HandleReadyz(deps.Lifecycle, deps.ReadinessChecks))
+
+	// Metrics are served here unless a separate admin port is configured
+	if deps.Metrics != nil && deps.ExposeMetrics {
+		r.Handle("/metrics", deps.Metrics.Handler())
+	}
+
+	// Public auth endpoints, throttled per client IP
+	r.Group(func(ar chi.Router) {
+		ar.Use(limiter.Limit("auth", deps.RateLimits.Auth))
+		ar.Post("/auth/register", HandleRegister(deps.UserRepo, deps.TokenRepo, deps.AccountTokenRepo, deps.Mailer, deps.AppBaseURL, tokenConfig, deps.Metrics))
+		ar.Post("/auth/login", HandleLogin(deps.UserRepo, deps.TokenRepo, tokenConfig, limiter, deps.Metrics))
+		ar.Post("/auth/refresh", HandleRefreshToken(deps.TokenRepo, tokenConfig))
+		ar.Post("/auth/password/forgot", HandleForgotPassword(deps.UserRepo, deps.AccountTokenRepo, deps.Mailer, deps.AppBaseURL))
+		ar.Post("/auth/password/reset", HandleResetPassword(deps.UserRepo, deps.TokenRepo, deps.AccountTokenRepo))
+		ar.Post("/auth/email/verify", HandleVerifyEmail(deps.UserRepo, deps.AccountTokenRepo))
+		ar.Post("/auth/email/change/confirm", HandleConfirmEmailChange(deps.UserRepo, deps.AccountTokenRepo, deps.Mailer))
 	})
 
-	// Public auth endpoints
-	r.Post("/auth/register", HandleRegister(deps.UserRepo, deps.TokenRepo, deps.JWTSecret))
-	r.Post("/auth/login", HandleLogin(deps.UserRepo, deps.TokenRepo, deps.JWTSecret))
+	// Public catalog of reaction types
+	r.Get("/reaction-types", HandleGetReactionTypes(deps.ReactionTypeRepo))
 
 	// Protected routes
 	r.Group(func(pr chi.Router) {
-		pr.Use(AuthMiddleware(deps.TokenRepo, deps.JWTSecret))
+		pr.Use(AuthMiddleware(deps.TokenRepo, deps.UserRepo, deps.JWTSecret))
 
 		pr.Get("/auth/verify", HandleVerifyAuth(deps.UserRepo))
 		pr.Post("/auth/logout", HandleLogOut(deps.TokenRepo))
+		pr.Get("/auth/sessions", HandleGetSessions(deps.TokenRepo))
+		pr.Delete("/auth/sessions", HandleRevokeAllSessions(deps.TokenRepo))
+		pr.Delete("/auth/sessions/{session_id}", HandleRevokeSession(deps.TokenRepo))
+		pr.Post("/auth/email/verify/resend", HandleResendVerificationEmail(deps.UserRepo, deps.AccountTokenRepo, deps.Mailer, deps.AppBaseURL))
 
 		// Uploads
-		pr.Post("/uploads/presign", HandleGetPresignedUploadURL(deps.S3Client))
+		pr.Post("/uploads/presign", HandleGetPresignedUploadURL(deps.S3Client, deps.PresignExpiry))
+
+		// Reaction type management
+		pr.Group(func(ar chi.Router) {
+			ar.Use(RequireRole(entity.RoleAdmin))
+			ar.Post("/reaction-types", HandleCreateReactionType(deps.ReactionTypeRepo))
+			ar.Post("/reaction-types/icons/presign", HandleGetReactionTypeIconUploadURL(deps.S3Client, deps.PresignExpiry))
+			ar.Put("/reaction-types/{reaction_type_id}", HandleUpdateReactionType(deps.ReactionTypeRepo))
+			ar.Delete("/reaction-types/{reaction_type_id}", HandleRetireReactionType(deps.ReactionTypeRepo))
+			ar.Post("/reaction-types/{reaction_type_id}/restore", HandleRestoreReactionType(deps.ReactionTypeRepo))
+		})
 
 		// Categories
 		pr.Route("/categories", func(cr chi.Router) {
 			cr.Get("/", HandleGetAllCategories(deps.CategoryRepo))
-			cr.Post("/", HandleCreateCategory(deps.CategoryRepo))
+			cr.With(RequireRole(entity.RoleAdmin)).Post("/", HandleCreateCategory(deps.CategoryRepo))
 			cr.Get("/{category_id}", HandleGetCategoryByID(deps.CategoryRepo))
-			cr.Get("/{category_id}/posts", HandleGetPostsByCategory(deps.PostRepo, deps.ReactionRepo, deps.ReactionTypeRepo))
-			cr.Post("/{category_id}/posts", HandleCreatePost(deps.PostRepo))
-			cr.Get("/{category_id}/posts/user", HandleGetUserPostsByCategory(deps.PostRepo, deps.ReactionRepo, deps.ReactionTypeRepo))
-			cr.Get("/{category_id}/comments/user", HandleGetUserCommentsByCategory(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo, deps.ReactionTypeRepo))
+			cr.Get("/{category_id}/posts", HandleGetPostsByCategory(deps.PostRepo, deps.ReactionRepo))
+			cr.With(RequireVerifiedEmail, limitPosts).Post("/{category_id}/posts", HandleCreatePost(deps.PostRepo, deps.Metrics))
+			cr.Get("/{category_id}/posts/user", HandleGetUserPostsByCategory(deps.PostRepo, deps.ReactionRepo))
+			cr.Get("/{category_id}/comments/user", HandleGetUserCommentsByCategory(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo))
+			cr.Get("/{category_id}/moderators", HandleGetCategoryModerators(deps.CategoryRepo, deps.ModeratorRepo, deps.UserRepo))
+			cr.With(RequireRole(entity.RoleAdmin)).Post("/{category_id}/moderators", HandleAddCategoryModerator(deps.CategoryRepo, deps.ModeratorRepo, deps.UserRepo))
+			cr.With(RequireRole(entity.RoleAdmin)).Delete("/{category_id}/moderators/{user_id}", HandleRemoveCategoryModerator(deps.ModeratorRepo))
 		})
 
 		// Posts
 		pr.Route("/posts", func(pr chi.Router) {
-			pr.Get("/{post_id}", HandleGetPost(deps.PostRepo, deps.ReactionRepo, deps.ReactionTypeRepo))
-			pr.Put("/{post_id}", HandleUpdatePost(deps.PostRepo))
-			pr.Delete("/{post_id}", HandleDeletePost(deps.PostRepo))
-			pr.Post("/{post_id}/react", HandleReactToPost(deps.ReactionRepo))
-			pr.Get("/{post_id}/comments", HandleGetCommentsByPost(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo, deps.ReactionTypeRepo, deps.PostRepo))
-			pr.Post("/{post_id}/comments", HandleCreateCommentOnPost(deps.CommentRepo, deps.PostRepo))
+			pr.Get("/{post_id}", HandleGetPost(deps.PostRepo, deps.ReactionRepo))
+			pr.Put("/{post_id}", HandleUpdatePost(deps.PostRepo, deps.ModeratorRepo))
+			pr.Delete("/{post_id}", HandleDeletePost(deps.PostRepo, deps.ModeratorRepo))
+			pr.With(limitReactions).Post("/{post_id}/react", HandleReactToPost(deps.PostRepo, deps.ReactionRepo, deps.ReactionTypeRepo, deps.Notifier, deps.Metrics))
+			pr.Delete("/{post_id}/react", HandleRemovePostReaction(deps.ReactionRepo))
+			pr.Get("/{post_id}/reactions", HandleGetPostReactions(deps.PostRepo, deps.ReactionRepo))
+			pr.Get("/{post_id}/comments", HandleGetCommentsByPost(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo, deps.PostRepo))
+			pr.With(RequireVerifiedEmail, limitComments).Post("/{post_id}/comments", HandleCreateCommentOnPost(deps.CommentRepo, deps.PostRepo, deps.Notifier, deps.Metrics))
 		})
 
 		// Comments
 		pr.Route("/comments", func(cr chi.Router) {
-			cr.Get("/{comment_id}", HandleGetComment(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo, deps.ReactionTypeRepo))
-			cr.Put("/{comment_id}", HandleUpdateComment(deps.CommentRepo))
-			cr.Delete("/{comment_id}", HandleDeleteComment(deps.CommentRepo))
-			cr.Get("/{comment_id}/replies", HandleGetRepliesByComment(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo, deps.ReactionTypeRepo))
-			cr.Post("/{comment_id}/replies", HandleCreateReplyToComment(deps.CommentRepo))
-			cr.Post("/{comment_id}/react", HandleReactToComment(deps.CommentRepo, deps.CommentReactionRepo, deps.ReactionTypeRepo))
+			cr.Get("/{comment_id}", HandleGetComment(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo))
+			cr.Put("/{comment_id}", HandleUpdateComment(deps.CommentRepo, deps.PostRepo, deps.ModeratorRepo))
+			cr.Delete("/{comment_id}", HandleDeleteComment(deps.CommentRepo, deps.PostRepo, deps.ModeratorRepo))
+			cr.Get("/{comment_id}/replies", HandleGetRepliesByComment(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo))
+			cr.With(RequireVerifiedEmail, limitComments).Post("/{comment_id}/replies", HandleCreateReplyToComment(deps.CommentRepo, deps.Notifier, deps.Metrics))
+			cr.With(limitReactions).Post("/{comment_id}/react", HandleReactToComment(deps.CommentRepo, deps.CommentReactionRepo, deps.ReactionTypeRepo, deps.Notifier, deps.Metrics))
+			cr.Delete("/{comment_id}/react", HandleRemoveCommentReaction(deps.CommentReactionRepo))
+			cr.Get("/{comment_id}/reactions", HandleGetCommentReactions(deps.CommentRepo, deps.CommentReactionRepo))
 		})
 
 		// User-scoped resources
-		pr.Get("/user/posts", HandleGetUserPosts(deps.PostRepo, deps.ReactionRepo, deps.ReactionTypeRepo))
-		pr.Get("/user/comments", HandleGetUserComments(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo, deps.ReactionTypeRepo))
-		pr.Get("/user/comments/category/{category_id}", HandleGetUserCommentsByCategory(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo, deps.ReactionTypeRepo))
+		pr.Get("/user/posts", HandleGetUserPosts(deps.PostRepo, deps.ReactionRepo))
+		pr.Get("/user/comments", HandleGetUserComments(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo))
+		pr.Get("/user/comments/category/{category_id}", HandleGetUserCommentsByCategory(deps.CommentRepo, deps.UserRepo, deps.CommentReactionRepo))
 		pr.Get("/user/categories", HandleGetUserCategories(deps.MembershipRepo, deps.CategoryRepo))
 		pr.Post("/user/subscribe", HandleSubscribeCategory(deps.UserRepo, deps.CategoryRepo, deps.MembershipRepo))
 		pr.Post("/user/unsubscribe", HandleUnsubscribeCategory(deps.MembershipRepo))
 		pr.Put("/user/profile-picture", HandleUploadProfilePicture(deps.UserRepo))
 		pr.Delete("/user/profile-picture", HandleDeleteProfilePicture(deps.UserRepo))
 		pr.Put("/user/username", HandleUpdateUsername(deps.UserRepo))
+		pr.Put("/user/password", HandleChangePassword(deps.UserRepo, deps.TokenRepo))
+		pr.Put("/user/email", HandleChangeEmail(deps.UserRepo, deps.AccountTokenRepo, deps.Mailer, deps.AppBaseURL))
 		pr.Delete("/user", HandleDeleteAccount(deps.UserRepo))
 
 		// Users
 		pr.Get("/users/{user_id}", HandleGetAccount(deps.UserRepo))
+		pr.With(RequireRole(entity.RoleAdmin)).Put("/users/{user_id}/role", HandleUpdateUserRole(deps.UserRepo))
+
+		// Home feed
+		pr.Get("/feed", HandleGetFeed(deps.PostRepo, deps.MembershipRepo, deps.ReactionRepo))
+
+		// Search
+		pr.Get("/search", HandleSearch(deps.SearchRepo, deps.UserRepo, deps.ReactionRepo, deps.CommentReactionRepo))
 
 		// Notifications
 		pr.Route("/notifications", func(nr chi.Router) {
 			nr.Get("/", HandleGetAllUserNotifications(deps.NotificationRepo))
 			nr.Get("/read", HandleGetAllReadNotifications(deps.NotificationRepo))
 			nr.Get("/unread", HandleGetAllUnreadNotifications(deps.NotificationRepo))
+			nr.Get("/stream", HandleNotificationStream(deps.NotificationRepo, deps.NotificationBroker))
 			nr.Put("/{notification_id}/read", HandleMarkNotificationAsRead(deps.NotificationRepo))
 			nr.Put("/{notification_id}/unread", HandleMarkNotificationAsUnread(deps.NotificationRepo))
 		})
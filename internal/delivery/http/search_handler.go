@@ -0,0 +1,238 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+)
+
+// maxSearchQueryLength caps the length of the search terms
+const maxSearchQueryLength = 200
+
+// PostSearchResult is a post matching a search with its relevance and highlighted snippet
+type PostSearchResult struct {
+	PostResponse
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// CommentSearchResult is a comment matching a search with its relevance and highlighted snippet
+type CommentSearchResult struct {
+	*CommentResponse
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// searchCursor holds the position reached in each result list
+// A list missing from a cursor has been fully read
+type searchCursor struct {
+	Posts    *rankCursor `json:"p,omitempty"`
+	Comments *rankCursor `json:"c,omitempty"`
+}
+
+// rankCursor is the encoded form of a repository.SearchCursor
+type rankCursor struct {
+	Rank float64 `json:"r"`
+	ID   int64   `json:"i"`
+}
+
+// SearchResponse is the payload response for a full-text search
+type SearchResponse struct {
+	Posts    []PostSearchResult    `json:"posts"`
+	Comments []CommentSearchResult `json:"comments"`
+}
+
+// @Summary Search posts and comments
+// @Description Full-text search over post headlines, post text and comments, ranked by relevance.
+// @Description Snippets are HTML escaped, matched terms are wrapped in <mark> tags.
+// @Tags search
+// @Security Bearer
+// @Param q query string true "Search terms, supports quoted phrases, OR and -exclusions"
+// @Param type query string false "Restrict results to posts or comments"
+// @Param category_id query int false "Category ID"
+// @Param author_id query int false "Author user ID"
+// @Param from query string false "Created at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Created before (RFC3339), or on or before (YYYY-MM-DD)"
+// @Param limit query int false "Page size of each result list, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} SearchResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /search [get]
+func HandleSearch(searchRepo *repository.SearchRepository, userRepo *repository.UserRepository, reactionRepo *repository.ReactionRepository, commentReactionRepo *repository.CommentReactionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			Unauthorized(w, "user not authenticated")
+			return
+		}
+
+		query := r.URL.Query()
+
+		filter := repository.SearchFilter{Query: strings.TrimSpace(query.Get("q"))}
+		if filter.Query == "" {
+			ValidationError(w, "q is required")
+			return
+		}
+		if len(filter.Query) > maxSearchQueryLength {
+			ValidationError(w, "q is too long")
+			return
+		}
+
+		kind := query.Get("type")
+		if kind != "" && kind != "posts" && kind != "comments" {
+			BadRequest(w, "type must be posts or comments")
+			return
+		}
+
+		if v := query.Get("category_id"); v != "" {
+			categoryID, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				BadRequest(w, "invalid category_id")
+				return
+			}
+			filter.CategoryID = &categoryID
+		}
+		if v := query.Get("author_id"); v != "" {
+			authorID, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				BadRequest(w, "invalid author_id")
+				return
+			}
+			filter.AuthorID = &authorID
+		}
+		if v := query.Get("from"); v != "" {
+			from, _, err := parseSearchDate(v)
+			if err != nil {
+				BadRequest(w, "invalid from date")
+				return
+			}
+			filter.From = &from
+		}
+		if v := query.Get("to"); v != "" {
+			to, dateOnly, err := parseSearchDate(v)
+			if err != nil {
+				BadRequest(w, "invalid to date")
+				return
+			}
+			// A plain date includes the whole day
+			if dateOnly {
+				to = to.AddDate(0, 0, 1)
+			}
+			filter.To = &to
+		}
+
+		limit, err := parseLimit(r)
+		if err != nil {
+			BadRequest(w, err.Error())
+			return
+		}
+
+		// Without a cursor both lists start from the top, with one only the unfinished lists continue
+		var cursor searchCursor
+		continuing := query.Get("cursor") != ""
+		if continuing {
+			if err := decodeCursor(query.Get("cursor"), &cursor); err != nil {
+				BadRequest(w, err.Error())
+				return
+			}
+		}
+		var next searchCursor
+
+		ctx := r.Context()
+		response := SearchResponse{
+			Posts:    []PostSearchResult{},
+			Comments: []CommentSearchResult{},
+		}
+
+		if kind != "comments" && (!continuing || cursor.Posts != nil) {
+			var after *repository.SearchCursor
+			if cursor.Posts != nil {
+				after = &repository.SearchCursor{Rank: cursor.Posts.Rank, ID: cursor.Posts.ID}
+			}
+			hits, err := searchRepo.SearchPosts(ctx, filter, after, limit+1)
+			if err != nil {
+				InternalError(w, r, "failed to search posts", err)
+				return
+			}
+			if int32(len(hits)) > limit {
+				hits = hits[:limit]
+				last := hits[len(hits)-1]
+				next.Posts = &rankCursor{Rank: last.Rank, ID: last.Post.ID}
+			}
+
+			posts := make([]*entity.Post, len(hits))
+			for i, hit := range hits {
+				posts[i] = hit.Post
+			}
+			responses, err := buildPostResponses(ctx, posts, userID, reactionRepo)
+			if err != nil {
+				InternalError(w, r, "failed to build posts", err)
+				return
+			}
+			for i, post := range responses {
+				response.Posts = append(response.Posts, PostSearchResult{
+					PostResponse: post,
+					Rank:         hits[i].Rank,
+					Snippet:      hits[i].Snippet,
+				})
+			}
+		}
+
+		if kind != "posts" && (!continuing || cursor.Comments != nil) {
+			var after *repository.SearchCursor
+			if cursor.Comments != nil {
+				after = &repository.SearchCursor{Rank: cursor.Comments.Rank, ID: cursor.Comments.ID}
+			}
+			hits, err := searchRepo.SearchComments(ctx, filter, after, limit+1)
+			if err != nil {
+				InternalError(w, r, "failed to search comments", err)
+				return
+			}
+			if int32(len(hits)) > limit {
+				hits = hits[:limit]
+				last := hits[len(hits)-1]
+				next.Comments = &rankCursor{Rank: last.Rank, ID: last.Comment.ID}
+			}
+
+			comments := make([]*entity.Comment, len(hits))
+			for i, hit := range hits {
+				comments[i] = hit.Comment
+			}
+			responses, err := buildCommentResponses(ctx, comments, userID, userRepo, commentReactionRepo)
+			if err != nil {
+				InternalError(w, r, "failed to build comments", err)
+				return
+			}
+			for i, comment := range responses {
+				response.Comments = append(response.Comments, CommentSearchResult{
+					CommentResponse: comment,
+					Rank:            hits[i].Rank,
+					Snippet:         hits[i].Snippet,
+				})
+			}
+		}
+
+		var nextCursor *string
+		if next.Posts != nil || next.Comments != nil {
+			encoded := encodeCursor(next)
+			nextCursor = &encoded
+		}
+
+		Paginated(w, response, nextCursor)
+	}
+}
+
+// parseSearchDate accepts either an RFC3339 timestamp or a plain YYYY-MM-DD date
+// The boolean reports whether a plain date was given
+func parseSearchDate(v string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	return t, false, err
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"my-chi-app/internal/database/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SessionResponse is the payload response when returning an active session
+type SessionResponse struct {
+	SessionID  string `json:"session_id"`
+	UserAgent  string `json:"user_agent"`
+	IPAddress  string `json:"ip_address"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"`
+}
+
+// @Summary List active sessions
+// @Description Retrieve all active sessions of the authenticated user with device and activity details
+// @Tags auth
+// @Security Bearer
+// @Success 200 {array} SessionResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/sessions [get]
+func HandleGetSessions(tokenRepo *repository.TokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			Unauthorized(w, "user not authenticated")
+			return
+		}
+
+		ctx := r.Context()
+
+		tokens, err := tokenRepo.ListActiveByUser(ctx, userID)
+		if err != nil {
+			InternalError(w, r, "failed to fetch sessions", err)
+			return
+		}
+
+		current := extractToken(r)
+
+		response := make([]SessionResponse, len(tokens))
+		for i, t := range tokens {
+			expiresAt := t.ExpiresAt
+			if t.RefreshExpiresAt != nil {
+				expiresAt = *t.RefreshExpiresAt
+			}
+			response[i] = SessionResponse{
+				SessionID:  t.SessionID,
+				UserAgent:  t.UserAgent,
+				IPAddress:  t.IPAddress,
+				CreatedAt:  t.SessionCreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+				LastUsedAt: t.LastUsedAt.Format("2006-01-02T15:04:05Z07:00"),
+				ExpiresAt:  expiresAt.Format("2006-01-02T15:04:05Z07:00"),
+				Current:    t.Token == current,
+			}
+		}
+
+		Success(w, response)
+	}
+}
+
+// @Summary Revoke a session
+// @Description Log out a single session of the authenticated user
+// @Tags auth
+// @Security Bearer
+// @Param session_id path string true "Session ID"
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/sessions/{session_id} [delete]
+func HandleRevokeSession(tokenRepo *repository.TokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			Unauthorized(w, "user not authenticated")
+			return
+		}
+
+		sessionID := chi.URLParam(r, "session_id")
+		if sessionID == "" {
+			BadRequest(w, "invalid session_id")
+			return
+		}
+
+		if err := tokenRepo.DeleteBySession(r.Context(), userID, sessionID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "session not found")
+				return
+			}
+			InternalError(w, r, "failed to revoke session", err)
+			return
+		}
+
+		Success(w, MessageResponse{
+			Message: "Session revoked successfully!",
+		})
+	}
+}
+
+// @Summary Log out everywhere
+// @Description Revoke every session of the authenticated user, including the current one
+// @Tags auth
+// @Security Bearer
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/sessions [delete]
+func HandleRevokeAllSessions(tokenRepo *repository.TokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			Unauthorized(w, "user not authenticated")
+			return
+		}
+
+		if _, err := tokenRepo.DeleteByUser(r.Context(), userID); err != nil {
+			InternalError(w, r, "failed to revoke sessions", err)
+			return
+		}
+
+		Success(w, MessageResponse{
+			Message: "Logged out of all sessions successfully!",
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// incomingTraceparent is the W3C trace context sent by the caller of the traced requests
+const (
+	incomingTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	incomingTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	incomingSpanID      = "00f067aa0ba902b7"
+)
+
+// serveTraced serves GET /posts/1/comments through the Trace middleware over the fake database
+// and returns the recorded spans by name
+func serveTraced(t *testing.T, connector *countingConnector) (int, map[string]tracetest.SpanStub) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(exporter, "webforum-test", 1)
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	repository.SetObserver(tracing.ObserveQuery)
+	t.Cleanup(func() {
+		repository.SetObserver(nil)
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	db := sql.OpenDB(connector)
+	t.Cleanup(func() { db.Close() })
+
+	r := chi.NewRouter()
+	r.Use(Trace)
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userIDKey, int64(1))))
+		})
+	})
+	r.Route("/posts", func(pr chi.Router) {
+		pr.Get("/{post_id}/comments", HandleGetCommentsByPost(repository.NewCommentRepository(db), repository.NewUserRepository(db),
+			repository.NewCommentReactionRepository(db), repository.NewPostRepository(db)))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/1/comments", nil)
+	req.Header.Set("traceparent", incomingTraceparent)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("flush spans: %v", err)
+	}
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	return rec.Code, spans
+}
+
+func TestTraceCommentsByPost(t *testing.T) {
+	status, spans := serveTraced(t, &countingConnector{})
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+
+	server, ok := spans["GET /posts/{post_id}/comments"]
+	if !ok {
+		t.Fatalf("no server span named after the route, got %v", spanNames(spans))
+	}
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span kind = %v", server.SpanKind)
+	}
+	if got := server.SpanContext.TraceID().String(); got != incomingTraceID {
+		t.Errorf("server span trace ID = %s, want the incoming %s", got, incomingTraceID)
+	}
+	if got := server.Parent.SpanID().String(); got != incomingSpanID {
+		t.Errorf("server span parent = %s, want the incoming %s", got, incomingSpanID)
+	}
+
+	for _, name := range []string{
+		"PostRepository.GetByID",
+		"CommentRepository.ListByPost",
+		"UserRepository.GetByIDs",
+		"CommentReactionRepository.CountTypesByComments",
+		"CommentReactionRepository.GetTypesByOwnerAndComments",
+	} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span, got %v", name, spanNames(spans))
+			continue
+		}
+		if span.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Errorf("%s is not a child of the server span", name)
+		}
+		if span.Status.Code != codes.Unset {
+			t.Errorf("%s status = %v, want unset", name, span.Status.Code)
+		}
+	}
+}
+
+func TestTraceFailedQuery(t *testing.T) {
+	status, spans := serveTraced(t, &countingConnector{fail: "FROM comments"})
+	if status != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", status, http.StatusInternalServerError)
+	}
+
+	if span := spans["CommentRepository.ListByPost"]; span.Status.Code != codes.Error || len(span.Events) == 0 {
+		t.Errorf("failed query span status = %v with %d events, want an error with the recorded error", span.Status.Code, len(span.Events))
+	}
+	if span := spans["GET /posts/{post_id}/comments"]; span.Status.Code != codes.Error {
+		t.Errorf("server span status = %v, want error", span.Status.Code)
+	}
+}
+
+func spanNames(spans map[string]tracetest.SpanStub) []string {
+	names := make([]string, 0, len(spans))
+	for name := range spans {
+		names = append(names, name)
+	}
+	return names
+}
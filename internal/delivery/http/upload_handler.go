@@ -1,7 +1,6 @@
 package http
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,9 +10,7 @@ import (
 
 // JSONResponse writes a JSON response with the given status code and data
 func JSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(data)
+	JSON(w, statusCode, data)
 }
 
 // @Summary Get presigned upload URL
@@ -25,7 +22,7 @@ func JSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /uploads/presign [post]
-func HandleGetPresignedUploadURL(s3Client *storage.S3Client) http.HandlerFunc {
+func HandleGetPresignedUploadURL(s3Client *storage.S3Client, expiry time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := GetUserID(r.Context())
 
@@ -41,10 +38,10 @@ func HandleGetPresignedUploadURL(s3Client *storage.S3Client) http.HandlerFunc {
 			return
 		}
 
-		presignedURL, err := s3Client.CreatePresignedUploadURL(r.Context(), strconv.FormatInt(userID, 10)+"/"+fileName, 15*time.Minute)
+		presignedURL, err := s3Client.CreatePresignedUploadURL(r.Context(), strconv.FormatInt(userID, 10)+"/"+fileName, expiry)
 
 		if err != nil {
-			InternalError(w, "failed to generate presigned URL")
+			InternalError(w, r, "failed to generate presigned URL", err)
 			return
 		}
 
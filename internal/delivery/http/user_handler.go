@@ -4,13 +4,16 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"my-chi-app/internal/database/repository"
 	"my-chi-app/internal/domain/entity"
+	"my-chi-app/internal/mail"
 
 	"github.com/go-chi/chi/v5"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // UploadProfilePictureRequest is the payload for uploading a profile picture
@@ -23,6 +26,18 @@ type UpdateUsernameRequest struct {
 	Username string `json:"username"`
 }
 
+// ChangePasswordRequest is the payload for changing the password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangeEmailRequest is the payload for changing the email address
+type ChangeEmailRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
 // SubscribeRequest is the payload for subscribing to a category
 type SubscribeRequest struct {
 	Category string `json:"category"`
@@ -75,13 +90,13 @@ func HandleUploadProfilePicture(userRepo *repository.UserRepository) http.Handle
 				NotFound(w, "user not found")
 				return
 			}
-			InternalError(w, "failed to fetch user")
+			InternalError(w, r, "failed to fetch user", err)
 			return
 		}
 
 		// Update profile picture
 		if err := userRepo.UpdateProfilePicture(ctx, userID, req.ProfilePicture); err != nil {
-			InternalError(w, "failed to update profile picture")
+			InternalError(w, r, "failed to update profile picture", err)
 			return
 		}
 
@@ -91,7 +106,6 @@ func HandleUploadProfilePicture(userRepo *repository.UserRepository) http.Handle
 			UserID:         user.ID,
 			Username:       user.Username,
 			Email:          user.Email,
-			Password:       user.Password,
 			ProfilePicture: user.ProfilePicture,
 			JoinedDate:     user.CreatedAt,
 		})
@@ -121,12 +135,12 @@ func HandleDeleteProfilePicture(userRepo *repository.UserRepository) http.Handle
 				NotFound(w, "user not found")
 				return
 			}
-			InternalError(w, "failed to fetch user")
+			InternalError(w, r, "failed to fetch user", err)
 			return
 		}
 
 		if err := userRepo.UpdateProfilePicture(ctx, userID, ""); err != nil {
-			InternalError(w, "failed to delete profile picture")
+			InternalError(w, r, "failed to delete profile picture", err)
 			return
 		}
 
@@ -136,7 +150,6 @@ func HandleDeleteProfilePicture(userRepo *repository.UserRepository) http.Handle
 			UserID:         user.ID,
 			Username:       user.Username,
 			Email:          user.Email,
-			Password:       user.Password,
 			ProfilePicture: user.ProfilePicture,
 			JoinedDate:     user.CreatedAt,
 		})
@@ -180,7 +193,7 @@ func HandleUpdateUsername(userRepo *repository.UserRepository) http.HandlerFunc
 				NotFound(w, "user not found")
 				return
 			}
-			InternalError(w, "failed to fetch user")
+			InternalError(w, r, "failed to fetch user", err)
 			return
 		}
 
@@ -189,7 +202,7 @@ func HandleUpdateUsername(userRepo *repository.UserRepository) http.HandlerFunc
 				Conflict(w, "username already exists")
 				return
 			}
-			InternalError(w, "failed to update username")
+			InternalError(w, r, "failed to update username", err)
 			return
 		}
 
@@ -199,13 +212,173 @@ func HandleUpdateUsername(userRepo *repository.UserRepository) http.HandlerFunc
 			UserID:         user.ID,
 			Username:       user.Username,
 			Email:          user.Email,
-			Password:       user.Password,
 			ProfilePicture: user.ProfilePicture,
 			JoinedDate:     user.CreatedAt,
 		})
 	}
 }
 
+// @Summary Change password
+// @Description Change the authenticated user's password and log out every other session
+// @Tags users
+// @Security Bearer
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /user/password [put]
+func HandleChangePassword(userRepo *repository.UserRepository, tokenRepo *repository.TokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			Unauthorized(w, "user not authenticated")
+			return
+		}
+
+		var req ChangePasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "invalid request body")
+			return
+		}
+
+		if req.CurrentPassword == "" || req.NewPassword == "" {
+			ValidationError(w, "current_password and new_password are required")
+			return
+		}
+		if len(req.NewPassword) < 8 {
+			ValidationError(w, "password must be at least 8 characters")
+			return
+		}
+
+		ctx := r.Context()
+
+		user, err := userRepo.GetByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "user not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch user", err)
+			return
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+			Unauthorized(w, "current password is incorrect")
+			return
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			InternalError(w, r, "failed to hash password", err)
+			return
+		}
+
+		if err := userRepo.UpdatePassword(ctx, userID, string(hashedPassword)); err != nil {
+			InternalError(w, r, "failed to update password", err)
+			return
+		}
+
+		// Keep the session making the change, revoke every other one
+		current, err := tokenRepo.GetByToken(ctx, extractToken(r))
+		if err != nil {
+			InternalError(w, r, "failed to fetch token", err)
+			return
+		}
+		if _, err := tokenRepo.DeleteByUserExceptSession(ctx, userID, current.SessionID); err != nil {
+			InternalError(w, r, "failed to revoke sessions", err)
+			return
+		}
+
+		Success(w, MessageResponse{
+			Message: "Password changed successfully!",
+		})
+	}
+}
+
+// @Summary Change email
+// @Description Request a change of the authenticated user's email.
+// @Description A confirmation link is sent to the new address and the change applies once it is confirmed.
+// @Tags users
+// @Security Bearer
+// @Param request body ChangeEmailRequest true "New email and current password"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /user/email [put]
+func HandleChangeEmail(userRepo *repository.UserRepository, accountTokenRepo *repository.AccountTokenRepository, mailer mail.Mailer, appBaseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			Unauthorized(w, "user not authenticated")
+			return
+		}
+
+		var req ChangeEmailRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			BadRequest(w, "invalid request body")
+			return
+		}
+
+		if req.Email == "" || req.Password == "" {
+			ValidationError(w, "email and password are required")
+			return
+		}
+		if !isValidEmail(req.Email) {
+			ValidationError(w, "invalid email format")
+			return
+		}
+
+		ctx := r.Context()
+
+		user, err := userRepo.GetByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				NotFound(w, "user not found")
+				return
+			}
+			InternalError(w, r, "failed to fetch user", err)
+			return
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+			Unauthorized(w, "password is incorrect")
+			return
+		}
+
+		if req.Email == user.Email {
+			ValidationError(w, "new email must be different from the current one")
+			return
+		}
+
+		// Checked again on confirmation since the address may be taken in between
+		if _, err := userRepo.GetByEmail(ctx, req.Email); err == nil {
+			Conflict(w, "email already exists")
+			return
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			InternalError(w, r, "failed to fetch user", err)
+			return
+		}
+
+		token, err := issueAccountToken(ctx, accountTokenRepo, userID, entity.AccountTokenEmailChange, &req.Email, emailChangeTTL)
+		if err != nil {
+			InternalError(w, r, "failed to create confirmation token", err)
+			return
+		}
+
+		sendAccountEmail(ctx, mailer, mail.Message{
+			To:      req.Email,
+			Subject: "Confirm your new email address",
+			Body: fmt.Sprintf("Hi %s,\n\nConfirm this address for your account with the link below. It expires in %s.\n\n%s\n",
+				user.Username, emailChangeTTL, accountLink(appBaseURL, "/confirm-email", token)),
+		})
+
+		Success(w, MessageResponse{
+			Message: "Confirmation email sent to the new address!",
+		})
+	}
+}
+
 // @Summary Get user account
 // @Description Retrieve public profile information for a specific user from user ID
 // @Tags users
@@ -238,7 +411,7 @@ func HandleGetAccount(userRepo *repository.UserRepository) http.HandlerFunc {
 				NotFound(w, "user not found")
 				return
 			}
-			InternalError(w, "failed to fetch user")
+			InternalError(w, r, "failed to fetch user", err)
 			return
 		}
 
@@ -246,7 +419,6 @@ func HandleGetAccount(userRepo *repository.UserRepository) http.HandlerFunc {
 			UserID:         user.ID,
 			Username:       user.Username,
 			Email:          user.Email,
-			Password:       user.Password,
 			ProfilePicture: user.ProfilePicture,
 			JoinedDate:     user.CreatedAt,
 		})
@@ -289,7 +461,7 @@ func HandleSubscribeCategory(userRepo *repository.UserRepository, categoryRepo *
 				NotFound(w, "user not found")
 				return
 			}
-			InternalError(w, "failed to fetch user")
+			InternalError(w, r, "failed to fetch user", err)
 			return
 		}
 
@@ -299,7 +471,7 @@ func HandleSubscribeCategory(userRepo *repository.UserRepository, categoryRepo *
 				NotFound(w, "category not found")
 				return
 			}
-			InternalError(w, "failed to fetch category")
+			InternalError(w, r, "failed to fetch category", err)
 			return
 		}
 
@@ -309,7 +481,7 @@ func HandleSubscribeCategory(userRepo *repository.UserRepository, categoryRepo *
 		}
 		_, err = membershipRepo.Create(ctx, membership)
 		if err != nil {
-			InternalError(w, "failed to subscribe to category")
+			InternalError(w, r, "failed to subscribe to category", err)
 			return
 		}
 
@@ -356,7 +528,7 @@ func HandleUnsubscribeCategory(membershipRepo *repository.MembershipRepository)
 				NotFound(w, "membership not found")
 				return
 			}
-			InternalError(w, "failed to fetch membership")
+			InternalError(w, r, "failed to fetch membership", err)
 			return
 		}
 
@@ -365,7 +537,7 @@ func HandleUnsubscribeCategory(membershipRepo *repository.MembershipRepository)
 				NotFound(w, "membership not found")
 				return
 			}
-			InternalError(w, "failed to unsubscribe from category")
+			InternalError(w, r, "failed to unsubscribe from category", err)
 			return
 		}
 
@@ -398,7 +570,7 @@ func HandleDeleteAccount(userRepo *repository.UserRepository) http.HandlerFunc {
 				NotFound(w, "user not found")
 				return
 			}
-			InternalError(w, "failed to delete account")
+			InternalError(w, r, "failed to delete account", err)
 			return
 		}
 
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// AccountToken is a single-use token proving control of an account's email
+// Only the SHA-256 hash of the token is stored, Payload carries purpose specific data such as a pending email
+type AccountToken struct {
+	ID        int64
+	UserID    int64
+	Purpose   string
+	TokenHash string
+	Payload   *string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Purposes an account token can be issued for
+const (
+	AccountTokenEmailVerification = "email_verification"
+	AccountTokenPasswordReset     = "password_reset"
+	AccountTokenEmailChange       = "email_change"
+)
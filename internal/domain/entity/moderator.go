@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// Moderator grants a user moderation rights in a category
+type Moderator struct {
+	ID         int64
+	CategoryID int64
+	UserID     int64
+	CreatedAt  time.Time
+}
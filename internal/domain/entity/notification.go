@@ -13,3 +13,16 @@ type Notification struct {
 	Status           bool
 	CreatedAt        time.Time
 }
+
+// Component types a notification can point at
+const (
+	NotificationComponentPost    = "post"
+	NotificationComponentComment = "comment"
+)
+
+// Kinds of activity that produce a notification
+const (
+	NotificationTypeComment  = "comment"
+	NotificationTypeReply    = "reply"
+	NotificationTypeReaction = "reaction"
+)
@@ -1,8 +1,12 @@
 package entity
 
+import "time"
+
 // ReactionType represents a type of reaction in ID system
+// RetiredAt is set once a type no longer accepts new reactions
 type ReactionType struct {
-	ID    int64
-	Name  string
-	Image *string
+	ID        int64
+	Name      string
+	Image     *string
+	RetiredAt *time.Time
 }
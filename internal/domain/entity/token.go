@@ -3,9 +3,20 @@ package entity
 import "time"
 
 // Token represents an authentication token for a user to use
+// Tokens sharing a SessionID belong to the same login and are rotated through refresh
+// CreatedAt is when this token was issued, SessionCreatedAt when the user signed in
 type Token struct {
-	ID        int64
-	UserID    int64
-	Token     string
-	ExpiresAt time.Time
+	ID               int64
+	UserID           int64
+	Token            string
+	ExpiresAt        time.Time
+	SessionID        string
+	RefreshTokenHash *string
+	RefreshExpiresAt *time.Time
+	UserAgent        string
+	IPAddress        string
+	CreatedAt        time.Time
+	LastUsedAt       time.Time
+	RotatedAt        *time.Time
+	SessionCreatedAt time.Time
 }
@@ -1,13 +1,32 @@
 package entity
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrUserSerialization is returned when a User is encoded directly
+var ErrUserSerialization = errors.New("entity.User must not be serialized, map it to a response type without credentials")
 
 // User represents an account in the forum
 type User struct {
-	ID             int64
-	Username       string
-	Email          string
-	Password       string
-	ProfilePicture *string
-	CreatedAt      time.Time
+	ID              int64
+	Username        string
+	Email           string
+	Password        string
+	ProfilePicture  *string
+	Role            string
+	EmailVerifiedAt *time.Time
+	CreatedAt       time.Time
+}
+
+// Global roles a user can hold, moderators are granted per category
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// MarshalJSON refuses to encode a User so the password hash can never leave the server
+func (User) MarshalJSON() ([]byte, error) {
+	return nil, ErrUserSerialization
 }
@@ -0,0 +1,193 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the name of every application series
+const namespace = "webforum"
+
+// queryBuckets are the histogram buckets of repository methods, in seconds
+// Queries are expected to be much faster than whole requests
+var queryBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5}
+
+// Metrics holds the Prometheus collectors of the application
+// A nil Metrics records nothing, so callers need no checks when metrics are disabled
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+
+	registrations prometheus.Counter
+	logins        *prometheus.CounterVec
+	posts         prometheus.Counter
+	comments      prometheus.Counter
+	reactions     *prometheus.CounterVec
+	notifications *prometheus.CounterVec
+}
+
+// New creates the collectors and registers them with the Go runtime, process and db pool collectors
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by method, route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Duration of repository methods by repository and method.",
+			Buckets:   queryBuckets,
+		}, []string{"repository", "method"}),
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "registrations_total",
+			Help:      "Users registered.",
+		}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by result, success or failure.",
+		}, []string{"result"}),
+		posts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "posts_created_total",
+			Help:      "Posts created.",
+		}),
+		comments: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "comments_created_total",
+			Help:      "Comments and replies created.",
+		}),
+		reactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reactions_created_total",
+			Help:      "Reactions added or changed by target, post or comment.",
+		}, []string{"target"}),
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "notifications_created_total",
+			Help:      "Notifications created by type.",
+		}, []string{"type"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, namespace),
+		m.httpRequests,
+		m.httpDuration,
+		m.queryDuration,
+		m.registrations,
+		m.logins,
+		m.posts,
+		m.comments,
+		m.reactions,
+		m.notifications,
+	)
+
+	// Expose the series with no observation yet so dashboards and alerts see zeros instead of gaps
+	m.logins.WithLabelValues("success")
+	m.logins.WithLabelValues("failure")
+	m.reactions.WithLabelValues("post")
+	m.reactions.WithLabelValues("comment")
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest records a served HTTP request
+// route is the chi route pattern, never the raw path, to keep the number of series bounded
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveQuery times a repository method, it matches repository.Observer
+func (m *Metrics) ObserveQuery(_ context.Context, repository, method string) func(error) {
+	if m == nil {
+		return func(error) {}
+	}
+	start := time.Now()
+	return func(error) {
+		m.queryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// UserRegistered counts a new account
+func (m *Metrics) UserRegistered() {
+	if m == nil {
+		return
+	}
+	m.registrations.Inc()
+}
+
+// LoginAttempted counts a login attempt with its result
+func (m *Metrics) LoginAttempted(success bool) {
+	if m == nil {
+		return
+	}
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	m.logins.WithLabelValues(result).Inc()
+}
+
+// PostCreated counts a new post
+func (m *Metrics) PostCreated() {
+	if m == nil {
+		return
+	}
+	m.posts.Inc()
+}
+
+// CommentCreated counts a new comment or reply
+func (m *Metrics) CommentCreated() {
+	if m == nil {
+		return
+	}
+	m.comments.Inc()
+}
+
+// ReactionCreated counts a reaction on a post or a comment
+func (m *Metrics) ReactionCreated(target string) {
+	if m == nil {
+		return
+	}
+	m.reactions.WithLabelValues(target).Inc()
+}
+
+// NotificationCreated counts a notification of the given type
+func (m *Metrics) NotificationCreated(notificationType string) {
+	if m == nil {
+		return
+	}
+	m.notifications.WithLabelValues(notificationType).Inc()
+}
@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"context"
+	"sync"
+
+	"my-chi-app/internal/domain/entity"
+)
+
+// subscriptionBuffer is the number of notifications queued per session before it is dropped
+const subscriptionBuffer = 32
+
+// Broker fans out newly created notifications to the connected sessions of their owner
+type Broker interface {
+	// Publish delivers a notification to every session subscribed for its owner
+	Publish(ctx context.Context, n *entity.Notification) error
+	// Subscribe registers a session for a user and returns its channel with an unsubscribe function
+	// The channel is closed if the session falls too far behind, clients should reconnect with their cursor
+	Subscribe(userID int64) (<-chan *entity.Notification, func())
+	// CloseSessions ends every session and refuses new ones so streams finish before shutdown
+	CloseSessions()
+}
+
+// MemoryBroker is an in-process Broker for a single application instance
+type MemoryBroker struct {
+	mu     sync.Mutex
+	subs   map[int64]map[*subscription]struct{}
+	closed bool
+}
+
+// subscription is a single connected session of a user
+type subscription struct {
+	ch     chan *entity.Notification
+	closed bool
+}
+
+// NewMemoryBroker creates a new MemoryBroker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subs: make(map[int64]map[*subscription]struct{})}
+}
+
+// Publish delivers the notification to all sessions of its owner without blocking
+func (b *MemoryBroker) Publish(_ context.Context, n *entity.Notification) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subs[n.OwnerID] {
+		select {
+		case s.ch <- n:
+		default:
+			// Slow session, close it so the client reconnects and replays from its cursor
+			b.remove(n.OwnerID, s)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a new session for the user
+func (b *MemoryBroker) Subscribe(userID int64) (<-chan *entity.Notification, func()) {
+	s := &subscription{ch: make(chan *entity.Notification, subscriptionBuffer)}
+
+	b.mu.Lock()
+	// Draining, hand out a closed channel so the client reconnects to another instance
+	if b.closed {
+		b.mu.Unlock()
+		close(s.ch)
+		return s.ch, func() {}
+	}
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*subscription]struct{})
+	}
+	b.subs[userID][s] = struct{}{}
+	b.mu.Unlock()
+
+	return s.ch, func() {
+		b.mu.Lock()
+		b.remove(userID, s)
+		b.mu.Unlock()
+	}
+}
+
+// CloseSessions closes the channel of every session and refuses new ones
+func (b *MemoryBroker) CloseSessions() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.removeAll()
+}
+
+// ResetSessions closes the channel of every session but keeps accepting new ones
+// Clients reconnect and replay from their cursor what this instance may have missed
+func (b *MemoryBroker) ResetSessions() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.removeAll()
+}
+
+// removeAll closes and forgets every session, the caller must hold the lock
+func (b *MemoryBroker) removeAll() {
+	for userID, sessions := range b.subs {
+		for s := range sessions {
+			b.remove(userID, s)
+		}
+	}
+}
+
+// remove closes and forgets a session, the caller must hold the lock
+func (b *MemoryBroker) remove(userID int64, s *subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.ch)
+
+	delete(b.subs[userID], s)
+	if len(b.subs[userID]) == 0 {
+		delete(b.subs, userID)
+	}
+}
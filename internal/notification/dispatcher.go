@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"context"
+	"log/slog"
+
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/domain/entity"
+	"my-chi-app/internal/metrics"
+)
+
+// Dispatcher creates notifications for forum activity and pushes them to connected sessions
+type Dispatcher struct {
+	repo       *repository.NotificationRepository
+	broker     Broker
+	appMetrics *metrics.Metrics
+}
+
+// NewDispatcher creates a new Dispatcher
+func NewDispatcher(repo *repository.NotificationRepository, broker Broker, appMetrics *metrics.Metrics) *Dispatcher {
+	return &Dispatcher{repo: repo, broker: broker, appMetrics: appMetrics}
+}
+
+// CommentOnPost notifies the post owner that the actor commented on their post
+func (d *Dispatcher) CommentOnPost(ctx context.Context, actorID int64, post *entity.Post) {
+	d.dispatch(ctx, &entity.Notification{
+		OwnerID:          post.OwnerID,
+		ActorID:          actorID,
+		ComponentType:    entity.NotificationComponentPost,
+		ComponentID:      post.ID,
+		NotificationType: entity.NotificationTypeComment,
+	}, false)
+}
+
+// ReplyToComment notifies the parent comment owner that the actor replied to their comment
+func (d *Dispatcher) ReplyToComment(ctx context.Context, actorID int64, parent *entity.Comment) {
+	d.dispatch(ctx, &entity.Notification{
+		OwnerID:          parent.OwnerID,
+		ActorID:          actorID,
+		ComponentType:    entity.NotificationComponentComment,
+		ComponentID:      parent.ID,
+		NotificationType: entity.NotificationTypeReply,
+	}, false)
+}
+
+// ReactToPost notifies the post owner that the actor reacted to their post
+// Repeated reactions from the same actor are collapsed into the first notification
+func (d *Dispatcher) ReactToPost(ctx context.Context, actorID int64, post *entity.Post) {
+	d.dispatch(ctx, &entity.Notification{
+		OwnerID:          post.OwnerID,
+		ActorID:          actorID,
+		ComponentType:    entity.NotificationComponentPost,
+		ComponentID:      post.ID,
+		NotificationType: entity.NotificationTypeReaction,
+	}, true)
+}
+
+// ReactToComment notifies the comment owner that the actor reacted to their comment
+// Repeated reactions from the same actor are collapsed into the first notification
+func (d *Dispatcher) ReactToComment(ctx context.Context, actorID int64, comment *entity.Comment) {
+	d.dispatch(ctx, &entity.Notification{
+		OwnerID:          comment.OwnerID,
+		ActorID:          actorID,
+		ComponentType:    entity.NotificationComponentComment,
+		ComponentID:      comment.ID,
+		NotificationType: entity.NotificationTypeReaction,
+	}, true)
+}
+
+// dispatch stores the notification unless the actor is notifying themselves
+// Failures are logged and never surfaced to the request that triggered them
+func (d *Dispatcher) dispatch(ctx context.Context, n *entity.Notification, collapse bool) {
+	if n.OwnerID == n.ActorID {
+		return
+	}
+
+	created := true
+	var err error
+	if collapse {
+		created, err = d.repo.CreateCollapsed(ctx, n)
+	} else {
+		_, err = d.repo.Create(ctx, n)
+	}
+	if err != nil {
+		slog.Error("failed to create notification", "type", n.NotificationType, "error", err)
+		return
+	}
+	if !created {
+		return
+	}
+	d.appMetrics.NotificationCreated(n.NotificationType)
+
+	if err := d.broker.Publish(ctx, n); err != nil {
+		slog.Error("failed to publish notification", "notification_id", n.ID, "error", err)
+	}
+}
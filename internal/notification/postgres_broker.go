@@ -0,0 +1,107 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"my-chi-app/internal/domain/entity"
+
+	"github.com/lib/pq"
+)
+
+// notificationChannel is the Postgres channel used to broadcast notifications between instances
+const notificationChannel = "notifications"
+
+// PostgresBroker is a Broker backed by Postgres LISTEN/NOTIFY
+// Every instance listens on the same channel and delivers to its own connected sessions
+type PostgresBroker struct {
+	db       *sql.DB
+	listener *pq.Listener
+	local    *MemoryBroker
+	done     chan struct{}
+}
+
+// NewPostgresBroker creates a new PostgresBroker and starts listening for notifications
+func NewPostgresBroker(db *sql.DB, dsn string) (*PostgresBroker, error) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("notification listener event", "event", ev, "error", err)
+		}
+	})
+	if err := listener.Listen(notificationChannel); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("error listening on %s: %w", notificationChannel, err)
+	}
+
+	b := &PostgresBroker{
+		db:       db,
+		listener: listener,
+		local:    NewMemoryBroker(),
+		done:     make(chan struct{}),
+	}
+	go b.run()
+	return b, nil
+}
+
+// Publish broadcasts the notification to all instances through pg_notify
+func (b *PostgresBroker) Publish(ctx context.Context, n *entity.Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	_, err = b.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, notificationChannel, string(payload))
+	return err
+}
+
+// Subscribe registers a new session for the user on this instance
+func (b *PostgresBroker) Subscribe(userID int64) (<-chan *entity.Notification, func()) {
+	return b.local.Subscribe(userID)
+}
+
+// CloseSessions ends the sessions connected to this instance
+func (b *PostgresBroker) CloseSessions() {
+	b.local.CloseSessions()
+}
+
+// Close stops listening for notifications
+func (b *PostgresBroker) Close() error {
+	close(b.done)
+	return b.listener.Close()
+}
+
+// run forwards notifications received from Postgres to the local sessions
+func (b *PostgresBroker) run() {
+	for {
+		select {
+		case <-b.done:
+			return
+		case pn, ok := <-b.listener.Notify:
+			if !ok {
+				return
+			}
+			// A nil notification signals a reconnect, notifications sent meanwhile were lost
+			// End the sessions so clients reconnect and replay them from their cursor
+			if pn == nil {
+				slog.Warn("notification listener reconnected, resetting sessions")
+				b.local.ResetSessions()
+				continue
+			}
+			var n entity.Notification
+			if err := json.Unmarshal([]byte(pn.Extra), &n); err != nil {
+				slog.Error("failed to decode notification payload", "error", err)
+				continue
+			}
+			_ = b.local.Publish(context.Background(), &n)
+		case <-time.After(90 * time.Second):
+			go func() {
+				if err := b.listener.Ping(); err != nil {
+					slog.Warn("notification listener ping failed", "error", err)
+				}
+			}()
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the MemoryStore drops expired entries
+const sweepInterval = time.Minute
+
+// MemoryStore is an in-process Store for a single application instance
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	failures  map[string]*failure
+	lastSweep time.Time
+}
+
+// bucket is the state of a token bucket, it is dropped once full again
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	expires time.Time
+}
+
+// failure counts the consecutive failed logins of an account
+type failure struct {
+	count       int
+	lockedUntil time.Time
+	expires     time.Time
+}
+
+// NewMemoryStore creates a new MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]*bucket),
+		failures: make(map[string]*failure),
+	}
+}
+
+// Take removes one token from the bucket at key
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	if !limit.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	burst := float64(limit.Burst)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = min(burst, b.tokens+now.Sub(b.updated).Seconds()*limit.rate())
+	b.updated = now
+
+	if b.tokens < 1 {
+		return Result{RetryAfter: limit.wait(b.tokens, 1)}, nil
+	}
+	b.tokens--
+	b.expires = now.Add(limit.wait(b.tokens, burst))
+	return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+// RecordFailure counts a failed login for key
+func (s *MemoryStore) RecordFailure(_ context.Context, key string, policy Lockout) (time.Duration, error) {
+	if !policy.Enabled() {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	f, ok := s.failures[key]
+	if !ok || now.After(f.expires) {
+		f = &failure{}
+		s.failures[key] = f
+	}
+	f.count++
+
+	delay := policy.delay(f.count)
+	f.lockedUntil = now.Add(delay)
+	f.expires = f.lockedUntil.Add(policy.Max)
+	return delay, nil
+}
+
+// LockedFor returns how long key remains locked
+func (s *MemoryStore) LockedFor(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.failures[key]
+	if !ok {
+		return 0, nil
+	}
+	return max(f.lockedUntil.Sub(time.Now()), 0), nil
+}
+
+// Reset forgets the login failures of key
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	return nil
+}
+
+// sweep drops full buckets and forgotten failures, at most once per sweepInterval
+// Must be called with mu held
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.After(b.expires) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, f := range s.failures {
+		if now.After(f.expires) {
+			delete(s.failures, key)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Burst: 2, Period: 100 * time.Millisecond}
+
+	for i, remaining := range []int{1, 0} {
+		res, err := store.Take(ctx, "key", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Remaining != remaining {
+			t.Fatalf("take %d = %+v, want allowed with %d remaining", i+1, res, remaining)
+		}
+	}
+
+	res, err := store.Take(ctx, "key", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed {
+		t.Fatalf("take past the burst = %+v, want denied", res)
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > limit.Period/2 {
+		t.Fatalf("retry after = %v, want up to the refill time of one token %v", res.RetryAfter, limit.Period/2)
+	}
+
+	// Other keys have their own bucket
+	if res, _ := store.Take(ctx, "other", limit); !res.Allowed {
+		t.Fatalf("take on another key = %+v, want allowed", res)
+	}
+
+	time.Sleep(res.RetryAfter)
+	if res, _ := store.Take(ctx, "key", limit); !res.Allowed {
+		t.Fatalf("take after waiting retry after = %+v, want allowed", res)
+	}
+
+	if res, _ := store.Take(ctx, "key", Limit{}); !res.Allowed {
+		t.Fatalf("take with the zero limit = %+v, want allowed", res)
+	}
+}
+
+func TestMemoryStoreRecordFailure(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	policy := Lockout{Threshold: 2, Base: time.Minute, Max: 4 * time.Minute}
+
+	for i, want := range []time.Duration{0, time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute} {
+		got, err := store.RecordFailure(ctx, "user", policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("failure %d locked for %v, want %v", i+1, got, want)
+		}
+	}
+
+	lockedFor, err := store.LockedFor(ctx, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lockedFor <= 3*time.Minute || lockedFor > 4*time.Minute {
+		t.Fatalf("locked for %v, want just under %v", lockedFor, 4*time.Minute)
+	}
+
+	if err := store.Reset(ctx, "user"); err != nil {
+		t.Fatal(err)
+	}
+	if lockedFor, _ := store.LockedFor(ctx, "user"); lockedFor != 0 {
+		t.Fatalf("locked for %v after reset, want 0", lockedFor)
+	}
+	if got, _ := store.RecordFailure(ctx, "user", policy); got != 0 {
+		t.Fatalf("first failure after reset locked for %v, want 0", got)
+	}
+}
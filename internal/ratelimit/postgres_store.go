@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// PostgresStore is a Store shared by every application instance through Postgres
+// Buckets are refilled with the database clock so instances with drifting clocks agree
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a new PostgresStore
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take removes one token from the bucket at key
+// The refill and the take happen in one statement, no row is returned when the bucket is empty
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	if !limit.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	burst, rate := float64(limit.Burst), limit.rate()
+
+	var tokens float64
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at, expires_at)
+		VALUES ($1, $2::float8 - 1, NOW(), NOW() + make_interval(secs => 1 / $3::float8))
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3::float8) - 1,
+			updated_at = NOW(),
+			expires_at = NOW() + make_interval(secs =>
+				($2::float8 + 1 - LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3::float8)) / $3::float8)
+		WHERE LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3::float8) >= 1
+		RETURNING tokens`,
+		key, burst, rate,
+	).Scan(&tokens)
+	if err == nil {
+		return Result{Allowed: true, Remaining: int(tokens)}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Result{}, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT LEAST($2::float8, tokens + EXTRACT(EPOCH FROM NOW() - updated_at) * $3::float8)
+		FROM rate_limit_buckets
+		WHERE key = $1`,
+		key, burst, rate,
+	).Scan(&tokens)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{RetryAfter: limit.wait(tokens, 1)}, nil
+}
+
+// RecordFailure counts a failed login for key
+func (s *PostgresStore) RecordFailure(ctx context.Context, key string, policy Lockout) (time.Duration, error) {
+	if !policy.Enabled() {
+		return 0, nil
+	}
+
+	var failures int
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO login_failures AS f (key, failures, expires_at)
+		VALUES ($1, 1, NOW() + make_interval(secs => $2::float8))
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN f.expires_at < NOW() THEN 1 ELSE f.failures + 1 END
+		RETURNING failures`,
+		key, policy.Max.Seconds(),
+	).Scan(&failures)
+	if err != nil {
+		return 0, err
+	}
+
+	delay := policy.delay(failures)
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE login_failures
+		SET locked_until = NOW() + make_interval(secs => $2::float8),
+		    expires_at = NOW() + make_interval(secs => $2::float8 + $3::float8)
+		WHERE key = $1`,
+		key, delay.Seconds(), policy.Max.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return delay, nil
+}
+
+// LockedFor returns how long key remains locked
+func (s *PostgresStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	var seconds float64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT GREATEST(EXTRACT(EPOCH FROM locked_until - NOW()), 0)
+		FROM login_failures
+		WHERE key = $1`,
+		key,
+	).Scan(&seconds)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// Reset forgets the login failures of key
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM login_failures WHERE key = $1`, key)
+	return err
+}
+
+// PurgeExpired deletes the full buckets and forgotten failures, returning the number of deleted rows
+func (s *PostgresStore) PurgeExpired(ctx context.Context) (int64, error) {
+	var total int64
+	for _, query := range []string{
+		`DELETE FROM rate_limit_buckets WHERE expires_at < NOW()`,
+		`DELETE FROM login_failures WHERE expires_at < NOW()`,
+	} {
+		result, err := s.db.ExecContext(ctx, query)
+		if err != nil {
+			return total, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket holding up to Burst requests, refilled at Burst requests per Period
+// The zero Limit disables limiting
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// ParseLimit parses a limit written as requests/period such as 10/1m, or off to disable it
+func ParseLimit(s string) (Limit, error) {
+	if s == "" || s == "off" {
+		return Limit{}, nil
+	}
+	burst, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit %q, expected requests/period such as 10/1m", s)
+	}
+	n, err := strconv.Atoi(burst)
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("invalid limit %q, requests must be a positive integer", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q, period must be a positive duration", s)
+	}
+	return Limit{Burst: n, Period: d}, nil
+}
+
+// Enabled reports whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Burst > 0 && l.Period > 0
+}
+
+// String formats the limit the way ParseLimit reads it
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	return strconv.Itoa(l.Burst) + "/" + l.Period.String()
+}
+
+// UnmarshalText lets limits be read from config files and environment variables
+func (l *Limit) UnmarshalText(text []byte) error {
+	parsed, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+// rate returns the number of tokens added to the bucket per second
+func (l Limit) rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// wait returns how long a bucket holding tokens needs to refill up to want tokens
+func (l Limit) wait(tokens, want float64) time.Duration {
+	if tokens >= want {
+		return 0
+	}
+	return time.Duration(math.Ceil((want - tokens) / l.rate() * float64(time.Second)))
+}
+
+// Lockout locks an account out after Threshold consecutive failures
+// The lock lasts Base and doubles with every further failure up to Max
+// Failures are forgotten once Max has passed without a new one
+type Lockout struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+}
+
+// Enabled reports whether the lockout applies
+func (p Lockout) Enabled() bool {
+	return p.Threshold > 0 && p.Base > 0
+}
+
+// delay returns how long the account is locked after failures consecutive failures
+func (p Lockout) delay(failures int) time.Duration {
+	if !p.Enabled() || failures < p.Threshold {
+		return 0
+	}
+	d := p.Base
+	for i := p.Threshold; i < failures && d < p.Max; i++ {
+		d *= 2
+	}
+	return min(d, p.Max)
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is how long to wait before a request is allowed again, 0 when allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the token buckets and login failures
+type Store interface {
+	// Take removes one token from the bucket at key, a new bucket starts full
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// RecordFailure counts a failed login for key and returns how long it is now locked, 0 when not locked
+	RecordFailure(ctx context.Context, key string, policy Lockout) (time.Duration, error)
+	// LockedFor returns how long key remains locked, 0 when it is not
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Reset forgets the login failures of key after a successful login
+	Reset(ctx context.Context, key string) error
+}
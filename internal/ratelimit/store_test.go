@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "", want: Limit{}},
+		{in: "off", want: Limit{}},
+		{in: "10/1m", want: Limit{Burst: 10, Period: time.Minute}},
+		{in: "1/500ms", want: Limit{Burst: 1, Period: 500 * time.Millisecond}},
+		{in: "5/1h30m", want: Limit{Burst: 5, Period: 90 * time.Minute}},
+		{in: "10", wantErr: true},
+		{in: "10/", wantErr: true},
+		{in: "/1m", wantErr: true},
+		{in: "0/1m", wantErr: true},
+		{in: "-1/1m", wantErr: true},
+		{in: "ten/1m", wantErr: true},
+		{in: "10/0s", wantErr: true},
+		{in: "10/-1m", wantErr: true},
+		{in: "10/minute", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseLimit(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseLimit(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		// The string form reads back as the same limit
+		if again, err := ParseLimit(got.String()); err != nil || again != got {
+			t.Errorf("ParseLimit(%q) = %+v, %v, want %+v", got.String(), again, err, got)
+		}
+	}
+}
+
+func TestLimitWait(t *testing.T) {
+	limit := Limit{Burst: 10, Period: time.Minute}
+	tests := []struct {
+		tokens, want float64
+		wait         time.Duration
+	}{
+		{tokens: 1, want: 1, wait: 0},
+		{tokens: 5, want: 1, wait: 0},
+		{tokens: 0, want: 1, wait: 6 * time.Second},
+		{tokens: 0.5, want: 1, wait: 3 * time.Second},
+		{tokens: 0, want: 10, wait: time.Minute},
+		{tokens: 9, want: 10, wait: 6 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := limit.wait(tt.tokens, tt.want); got != tt.wait {
+			t.Errorf("wait(%v, %v) = %v, want %v", tt.tokens, tt.want, got, tt.wait)
+		}
+	}
+
+	// Waits round up so a client retrying after them finds the token refilled
+	odd := Limit{Burst: 3, Period: time.Second}
+	if got := odd.wait(0, 1); got < time.Second/3 {
+		t.Errorf("wait(0, 1) = %v, shorter than the refill time of one token", got)
+	}
+}
+
+func TestLockoutDelay(t *testing.T) {
+	policy := Lockout{Threshold: 3, Base: time.Minute, Max: 10 * time.Minute}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: 0},
+		{failures: 2, want: 0},
+		{failures: 3, want: time.Minute},
+		{failures: 4, want: 2 * time.Minute},
+		{failures: 5, want: 4 * time.Minute},
+		{failures: 6, want: 8 * time.Minute},
+		{failures: 7, want: 10 * time.Minute},
+		{failures: 100, want: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.delay(tt.failures); got != tt.want {
+			t.Errorf("delay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+
+	disabled := []Lockout{
+		{},
+		{Threshold: 0, Base: time.Minute, Max: time.Hour},
+		{Threshold: 3, Base: 0, Max: time.Hour},
+	}
+	for _, p := range disabled {
+		if got := p.delay(100); got != 0 {
+			t.Errorf("%+v delay(100) = %v, want 0", p, got)
+		}
+	}
+}
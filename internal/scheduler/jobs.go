@@ -0,0 +1,129 @@
+package scheduler
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"my-chi-app/internal/database/repository"
+	"my-chi-app/internal/ratelimit"
+	"my-chi-app/internal/storage"
+)
+
+// PurgeTokensJob deletes sessions and account tokens that expired more than grace ago
+func PurgeTokensJob(interval, jitter, grace time.Duration, tokenRepo *repository.TokenRepository, accountTokenRepo *repository.AccountTokenRepository) Job {
+	return Job{
+		Name:     "purge-tokens",
+		Interval: interval,
+		Jitter:   jitter,
+		Run: func(ctx context.Context) (int64, error) {
+			cutoff := time.Now().Add(-grace)
+			sessions, err := tokenRepo.PurgeExpired(ctx, cutoff)
+			if err != nil {
+				return 0, err
+			}
+			accountTokens, err := accountTokenRepo.PurgeExpired(ctx, cutoff)
+			return sessions + accountTokens, err
+		},
+	}
+}
+
+// NotificationCleanupJob deletes read notifications older than retention
+func NotificationCleanupJob(interval, jitter, retention time.Duration, notificationRepo *repository.NotificationRepository) Job {
+	return Job{
+		Name:     "notification-cleanup",
+		Interval: interval,
+		Jitter:   jitter,
+		Run: func(ctx context.Context) (int64, error) {
+			return notificationRepo.DeleteReadBefore(ctx, time.Now().Add(-retention))
+		},
+	}
+}
+
+// RankingRefreshJob recomputes the ranking counters behind the sorted post feeds
+func RankingRefreshJob(interval, jitter time.Duration, postRepo *repository.PostRepository) Job {
+	return Job{
+		Name:     "ranking-refresh",
+		Interval: interval,
+		Jitter:   jitter,
+		Run:      postRepo.RefreshStats,
+	}
+}
+
+// UploadCleanupJob deletes uploaded objects older than minAge that no user, post, comment or reaction type references
+// minAge leaves clients time to save the image after uploading it with a presigned URL
+func UploadCleanupJob(interval, jitter, minAge time.Duration, imageRepo *repository.ImageRepository, s3Client *storage.S3Client) Job {
+	return Job{
+		Name:     "upload-cleanup",
+		Interval: interval,
+		Jitter:   jitter,
+		Run: func(ctx context.Context) (int64, error) {
+			// List objects first so an image saved meanwhile is seen as referenced
+			objects, err := s3Client.ListObjects(ctx, "")
+			if err != nil {
+				return 0, err
+			}
+
+			refs, err := imageRepo.ListReferences(ctx)
+			if err != nil {
+				return 0, err
+			}
+			referenced := make(map[string]struct{}, len(refs))
+			for _, ref := range refs {
+				referenced[objectKey(ref)] = struct{}{}
+			}
+
+			cutoff := time.Now().Add(-minAge)
+			var orphans []string
+			for _, obj := range objects {
+				if obj.LastModified.After(cutoff) || !isUploadKey(obj.Key) {
+					continue
+				}
+				if _, ok := referenced[obj.Key]; !ok {
+					orphans = append(orphans, obj.Key)
+				}
+			}
+
+			if err := s3Client.DeleteObjects(ctx, orphans); err != nil {
+				return 0, err
+			}
+			return int64(len(orphans)), nil
+		},
+	}
+}
+
+// objectKey extracts the object key from an image reference
+// References are either object URLs, possibly presigned, or bare keys
+func objectKey(ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return strings.TrimPrefix(u.Path, "/")
+}
+
+// isUploadKey reports whether a key was created by the upload endpoints
+// User uploads live under the user ID and reaction type icons under reaction-types/
+func isUploadKey(key string) bool {
+	prefix, _, ok := strings.Cut(key, "/")
+	if !ok {
+		return false
+	}
+	if prefix == "reaction-types" {
+		return true
+	}
+	_, err := strconv.ParseInt(prefix, 10, 64)
+	return err == nil
+}
+
+// RateLimitCleanupJob deletes the token buckets and login failures that no longer hold any state
+func RateLimitCleanupJob(interval, jitter time.Duration, store *ratelimit.PostgresStore) Job {
+	return Job{
+		Name:     "rate-limit-cleanup",
+		Interval: interval,
+		Jitter:   jitter,
+		Run:      store.PurgeExpired,
+	}
+}
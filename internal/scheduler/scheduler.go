@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Job is a maintenance task run periodically by the scheduler
+// Run returns the number of rows or objects it affected, reported in the job logs
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Jitter   time.Duration
+	Run      func(ctx context.Context) (int64, error)
+}
+
+// Scheduler runs jobs in the background of the server
+// Each run holds a Postgres advisory lock named after the job so only one replica runs it at a time
+type Scheduler struct {
+	db     *sql.DB
+	logger *slog.Logger
+	jobs   []Job
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a new Scheduler
+func New(db *sql.DB, logger *slog.Logger) *Scheduler {
+	return &Scheduler{db: db, logger: logger}
+}
+
+// Add registers a job, jobs with a non positive interval are disabled
+// Must be called before Start
+func (s *Scheduler) Add(job Job) {
+	if job.Interval <= 0 {
+		s.logger.Info("job disabled", "job", job.Name)
+		return
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every registered job in its own goroutine until Stop is called or ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+}
+
+// Stop cancels the running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// loop runs a job after a random part of its jitter, then every interval plus jitter
+// The initial jitter keeps replicas started together from contending for the lock
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	timer := time.NewTimer(jitter(job.Jitter))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		s.runOnce(ctx, job)
+		timer.Reset(job.Interval + jitter(job.Jitter))
+	}
+}
+
+// runOnce runs a job if no other replica holds its lock and logs the outcome
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	logger := s.logger.With("job", job.Name)
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		logger.Error("job failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	key := lockKey(job.Name)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		logger.Error("job failed", "error", err)
+		return
+	}
+	if !locked {
+		logger.Debug("job skipped, running on another instance")
+		return
+	}
+	// Unlock on a fresh context so a cancelled ctx still releases the lock
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+
+	start := time.Now()
+	affected, err := job.Run(ctx)
+	duration := time.Since(start)
+	if err != nil {
+		logger.Error("job failed", "duration", duration, "error", err)
+		return
+	}
+	logger.Info("job completed", "duration", duration, "affected", affected)
+}
+
+// lockKey derives the advisory lock key of a job from its name
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scheduler:" + name))
+	return int64(h.Sum64())
+}
+
+// jitter returns a random duration in [0, max)
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
@@ -5,14 +5,28 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"time"
 
+	"my-chi-app/internal/tracing"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Object describes an object stored in the bucket
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
 // S3Client handles all S3 operations
 type S3Client struct {
 	client *s3.Client
@@ -36,15 +50,18 @@ func NewS3Client(ctx context.Context, bucket, region string) (*S3Client, error)
 
 // UploadFile uploads a local file to S3 with .env specified the key
 func (sc *S3Client) UploadFile(ctx context.Context, filePath, key string) error {
+	ctx, span := sc.startSpan(ctx, "UploadFile", attribute.String("s3.key", key))
+	defer span.End()
+
 	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("error opening file: %w", err)
+		return failSpan(span, fmt.Errorf("error opening file: %w", err))
 	}
 	defer file.Close()
 
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, file); err != nil {
-		return fmt.Errorf("error reading file: %w", err)
+		return failSpan(span, fmt.Errorf("error reading file: %w", err))
 	}
 
 	_, err = sc.client.PutObject(ctx, &s3.PutObjectInput{
@@ -53,15 +70,18 @@ func (sc *S3Client) UploadFile(ctx context.Context, filePath, key string) error
 		Body:   bytes.NewReader(buf.Bytes()),
 	})
 	if err != nil {
-		return fmt.Errorf("error uploading file: %w", err)
+		return failSpan(span, fmt.Errorf("error uploading file: %w", err))
 	}
 
-	fmt.Println("File uploaded successfully:", filePath, "to key:", key)
+	slog.DebugContext(ctx, "uploaded file", "key", key)
 	return nil
 }
 
 // CreatePresignedUploadURL generates a presigned PUT URL for uploading files to S3
 func (sc *S3Client) CreatePresignedUploadURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	ctx, span := sc.startSpan(ctx, "CreatePresignedUploadURL", attribute.String("s3.key", key))
+	defer span.End()
+
 	presignClient := s3.NewPresignClient(sc.client)
 
 	result, err := presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
@@ -71,13 +91,95 @@ func (sc *S3Client) CreatePresignedUploadURL(ctx context.Context, key string, ex
 		opts.Expires = expiry
 	})
 	if err != nil {
-		return "", fmt.Errorf("error creating presigned URL: %w", err)
+		return "", failSpan(span, fmt.Errorf("error creating presigned URL: %w", err))
 	}
 
 	return result.URL, nil
 }
 
+// ListObjects returns every object whose key starts with prefix, an empty prefix lists the whole bucket
+func (sc *S3Client) ListObjects(ctx context.Context, prefix string) ([]Object, error) {
+	ctx, span := sc.startSpan(ctx, "ListObjects", attribute.String("s3.prefix", prefix))
+	defer span.End()
+
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(sc.bucket)}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+
+	var objects []Object
+	paginator := s3.NewListObjectsV2Paginator(sc.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, failSpan(span, fmt.Errorf("error listing objects: %w", err))
+		}
+		for _, obj := range page.Contents {
+			objects = append(objects, Object{
+				Key:          aws.ToString(obj.Key),
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: aws.ToTime(obj.LastModified),
+			})
+		}
+	}
+	span.SetAttributes(attribute.Int("s3.objects", len(objects)))
+	return objects, nil
+}
+
+// DeleteObjects removes the given keys from the bucket, in batches of at most 1000 keys
+func (sc *S3Client) DeleteObjects(ctx context.Context, keys []string) error {
+	ctx, span := sc.startSpan(ctx, "DeleteObjects", attribute.Int("s3.objects", len(keys)))
+	defer span.End()
+
+	const batchSize = 1000
+	for start := 0; start < len(keys); start += batchSize {
+		end := min(start+batchSize, len(keys))
+
+		ids := make([]types.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			ids = append(ids, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		out, err := sc.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(sc.bucket),
+			Delete: &types.Delete{Objects: ids, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return failSpan(span, fmt.Errorf("error deleting objects: %w", err))
+		}
+		if len(out.Errors) > 0 {
+			return failSpan(span, fmt.Errorf("error deleting object %s: %s", aws.ToString(out.Errors[0].Key), aws.ToString(out.Errors[0].Message)))
+		}
+	}
+	return nil
+}
+
+// Ping checks that the bucket exists and the credentials can access it
+func (sc *S3Client) Ping(ctx context.Context) error {
+	ctx, span := sc.startSpan(ctx, "Ping")
+	defer span.End()
+
+	_, err := sc.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(sc.bucket)})
+	if err != nil {
+		return failSpan(span, fmt.Errorf("error reaching bucket: %w", err))
+	}
+	return nil
+}
+
 // GetObjectURL returns the public URL for an object in S
 func (sc *S3Client) GetObjectURL(key string) string {
 	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", sc.bucket, sc.region, key)
 }
+
+// startSpan starts the span of an S3Client method
+func (sc *S3Client) startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("rpc.system", "aws-api"), attribute.String("s3.bucket", sc.bucket))
+	return tracing.Tracer().Start(ctx, "S3Client."+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// failSpan marks span as failed with err and returns err
+func failSpan(span trace.Span, err error) error {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	return err
+}